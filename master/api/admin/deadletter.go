@@ -0,0 +1,151 @@
+package admin
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type deadLetterListResponse struct {
+	Succeed bool                `json:"succeed"`
+	Data    []models.DeadLetter `json:"data"`
+	Total   int64               `json:"total"`
+	TraceID string              `json:"traceID"`
+}
+
+type deadLetterResponse struct {
+	Succeed bool               `json:"succeed"`
+	Data    *models.DeadLetter `json:"data"`
+	TraceID string             `json:"traceID"`
+}
+
+const maxPageSize = 100
+
+// ListDeadLetters godoc
+// @Summary List dead letters
+// @Description List results that could not be applied, newest first, without their body. Admin only.
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page, from 1" default(1)
+// @Param page_size query int false "Page size, at most 100" default(20)
+// @Success 200 {object} deadLetterListResponse "Successful response with dead letters"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /admin/dead-letter [get]
+func ListDeadLetters(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	page, err1 := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, err2 := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err1 != nil || err2 != nil || page < 1 || pageSize < 1 || pageSize > maxPageSize {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "page must be positive and page_size between 1 and 100",
+			Message: "Wrong Pagination",
+			TraceID: traceID,
+		})
+		return
+	}
+	deadLetters, total, err := taskservice.GetService().ListDeadLetters(c, page, pageSize)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, deadLetterListResponse{
+		Succeed: true,
+		Data:    deadLetters,
+		Total:   total,
+		TraceID: traceID,
+	})
+}
+
+// GetDeadLetter godoc
+// @Summary Get a dead letter
+// @Description Get a dead letter with its message body and failure reason. Admin only.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Dead letter ID"
+// @Success 200 {object} deadLetterResponse "Successful response with the dead letter"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /admin/dead-letter/{id} [get]
+func GetDeadLetter(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: "Wrong Dead Letter ID",
+			TraceID: traceID,
+		})
+		return
+	}
+	deadLetter, serviceErr := taskservice.GetService().GetDeadLetter(c, uint(id))
+	if serviceErr != nil {
+		c.JSON(serviceErr.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   serviceErr.Error(),
+			Message: serviceErr.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, deadLetterResponse{
+		Succeed: true,
+		Data:    deadLetter,
+		TraceID: traceID,
+	})
+}
+
+// ReplayDeadLetter godoc
+// @Summary Replay a dead letter
+// @Description Publish a dead letter to its original queue again. Admin only.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Dead letter ID"
+// @Success 200 {object} deadLetterResponse "Successful response with the dead letter"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /admin/dead-letter/{id}/replay [post]
+func ReplayDeadLetter(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: "Wrong Dead Letter ID",
+			TraceID: traceID,
+		})
+		return
+	}
+	deadLetter, serviceErr := taskservice.GetService().ReplayDeadLetter(c, uint(id))
+	if serviceErr != nil {
+		c.JSON(serviceErr.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   serviceErr.Error(),
+			Message: serviceErr.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, deadLetterResponse{
+		Succeed: true,
+		Data:    deadLetter,
+		TraceID: traceID,
+	})
+}
@@ -0,0 +1,94 @@
+package fleet
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type queryFleetResponse struct {
+	Succeed bool   `json:"succeed"`
+	Data    string `json:"data"` // uuid of the query
+	TraceID string `json:"traceID"`
+}
+
+type inventoryResponse struct {
+	Succeed bool                    `json:"succeed"`
+	Data    []models.FleetInventory `json:"data"`
+	TraceID string                  `json:"traceID"`
+}
+
+func parseAccountID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "id must be positive",
+			Message: "Wrong Account ID",
+			TraceID: c.GetString("traceID"),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// QueryFleet godoc
+// @Summary Query the fleet of an account
+// @Description Ask a node for the ship counts of every planet of the account. The inventory is replaced once the result arrives, tasks are checked against it before dispatch.
+// @Tags fleet
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} queryFleetResponse "Successful response with the uuid of the query"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /account/{id}/fleet/query [post]
+func QueryFleet(c *gin.Context) {
+	id, ok := parseAccountID(c)
+	if !ok {
+		return
+	}
+	uuid, err := taskservice.GetService().QueryFleet(c, id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, queryFleetResponse{
+		Succeed: true,
+		Data:    uuid,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// GetFleetInventory godoc
+// @Summary Get the fleet inventory of an account
+// @Description Get the last known ship counts of the planets of an account
+// @Tags fleet
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} inventoryResponse "Successful response with the ships of every planet"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /account/{id}/fleet [get]
+func GetFleetInventory(c *gin.Context) {
+	id, ok := parseAccountID(c)
+	if !ok {
+		return
+	}
+	inventory, err := taskservice.GetService().GetFleetInventory(c, id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, inventoryResponse{
+		Succeed: true,
+		Data:    inventory,
+		TraceID: c.GetString("traceID"),
+	})
+}
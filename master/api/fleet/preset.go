@@ -0,0 +1,262 @@
+package fleet
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"GalaxyEmpireWeb/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type presetRequest struct {
+	Name      string          `json:"name"`
+	AccountID uint            `json:"account_id"` // only on create, 0 for every account of the user
+	Ships     models.FleetDTO `json:"ships"`
+}
+
+type presetResponse struct {
+	Succeed bool                `json:"succeed"`
+	Data    *models.FleetPreset `json:"data"`
+	TraceID string              `json:"traceID"`
+}
+
+type presetListResponse struct {
+	Succeed bool                 `json:"succeed"`
+	Data    []models.FleetPreset `json:"data"`
+	TraceID string               `json:"traceID"`
+}
+
+type presetTasksResponse struct {
+	Succeed bool              `json:"succeed"`
+	Data    []*models.TaskDTO `json:"data"`
+	TraceID string            `json:"traceID"`
+}
+
+func parsePresetID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "id must be positive",
+			Message: "Wrong Fleet Preset ID",
+			TraceID: c.GetString("traceID"),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func badRequest(c *gin.Context, err error, msg string) {
+	c.JSON(http.StatusBadRequest, api.ErrorResponse{
+		Succeed: false,
+		Error:   err.Error(),
+		Message: msg,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+func serviceError(c *gin.Context, err *utils.ServiceError) {
+	c.JSON(err.StatusCode(), api.ErrorResponse{
+		Succeed: false,
+		Error:   err.Error(),
+		Message: err.Msg(),
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// CreateFleetPreset godoc
+// @Summary Create a fleet preset
+// @Description Save a named ship composition that tasks can reference through fleet_preset_id
+// @Tags fleet
+// @Accept json
+// @Produce json
+// @Param preset body presetRequest true "Name, optional account and ships"
+// @Success 200 {object} presetResponse "Successful response with the preset"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /fleet-preset [post]
+func CreateFleetPreset(c *gin.Context) {
+	var req presetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err, "Wrong Request Body")
+		return
+	}
+	preset := &models.FleetPreset{Name: req.Name, AccountID: req.AccountID, Ships: req.Ships}
+	if err := taskservice.GetService().CreateFleetPreset(c, preset); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, presetResponse{
+		Succeed: true,
+		Data:    preset,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// ListFleetPresets godoc
+// @Summary List fleet presets
+// @Description List the fleet presets of the current user
+// @Tags fleet
+// @Produce json
+// @Param account_id query int false "Only the presets this account may use"
+// @Success 200 {object} presetListResponse "Successful response with presets"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /fleet-preset [get]
+func ListFleetPresets(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.DefaultQuery("account_id", "0"))
+	if err != nil || accountID < 0 {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "account_id must not be negative",
+			Message: "Wrong Account ID",
+			TraceID: c.GetString("traceID"),
+		})
+		return
+	}
+	presets, serviceErr := taskservice.GetService().ListFleetPresets(c, uint(accountID))
+	if serviceErr != nil {
+		serviceError(c, serviceErr)
+		return
+	}
+	c.JSON(http.StatusOK, presetListResponse{
+		Succeed: true,
+		Data:    presets,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// GetFleetPreset godoc
+// @Summary Get a fleet preset
+// @Tags fleet
+// @Produce json
+// @Param id path int true "Fleet preset ID"
+// @Success 200 {object} presetResponse "Successful response with the preset"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /fleet-preset/{id} [get]
+func GetFleetPreset(c *gin.Context) {
+	id, ok := parsePresetID(c)
+	if !ok {
+		return
+	}
+	preset, err := taskservice.GetService().GetFleetPreset(c, id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, presetResponse{
+		Succeed: true,
+		Data:    preset,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// UpdateFleetPreset godoc
+// @Summary Update a fleet preset
+// @Description Rename a preset and replace its ships, every task using it sends the new ships from its next run on
+// @Tags fleet
+// @Accept json
+// @Produce json
+// @Param id path int true "Fleet preset ID"
+// @Param preset body presetRequest true "Name and ships, account_id is ignored"
+// @Success 200 {object} presetResponse "Successful response with the preset"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /fleet-preset/{id} [put]
+func UpdateFleetPreset(c *gin.Context) {
+	id, ok := parsePresetID(c)
+	if !ok {
+		return
+	}
+	var req presetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err, "Wrong Request Body")
+		return
+	}
+	preset, err := taskservice.GetService().UpdateFleetPreset(c, id, &models.FleetPreset{Name: req.Name, Ships: req.Ships})
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, presetResponse{
+		Succeed: true,
+		Data:    preset,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// GetFleetPresetTasks godoc
+// @Summary Get the tasks using a fleet preset
+// @Description List the tasks referencing a preset, to check before deleting it
+// @Tags fleet
+// @Produce json
+// @Param id path int true "Fleet preset ID"
+// @Success 200 {object} presetTasksResponse "Successful response with tasks"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /fleet-preset/{id}/tasks [get]
+func GetFleetPresetTasks(c *gin.Context) {
+	id, ok := parsePresetID(c)
+	if !ok {
+		return
+	}
+	tasks, err := taskservice.GetService().GetFleetPresetTasks(c, id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	data := make([]*models.TaskDTO, 0, len(tasks))
+	for _, task := range tasks {
+		data = append(data, task.ToDTO())
+	}
+	c.JSON(http.StatusOK, presetTasksResponse{
+		Succeed: true,
+		Data:    data,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// DeleteFleetPreset godoc
+// @Summary Delete a fleet preset
+// @Description Delete a preset no task uses. With force the tasks using it keep its ships as their own fleet.
+// @Tags fleet
+// @Produce json
+// @Param id path int true "Fleet preset ID"
+// @Param force query bool false "Detach the tasks using the preset" default(false)
+// @Success 200 {object} presetResponse "Successful response"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 409 {object} api.ErrorResponse "Tasks still use the preset"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /fleet-preset/{id} [delete]
+func DeleteFleetPreset(c *gin.Context) {
+	id, ok := parsePresetID(c)
+	if !ok {
+		return
+	}
+	force, err := strconv.ParseBool(c.DefaultQuery("force", "false"))
+	if err != nil {
+		badRequest(c, err, "Wrong Force")
+		return
+	}
+	if err := taskservice.GetService().DeleteFleetPreset(c, id, force); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, presetResponse{
+		Succeed: true,
+		TraceID: c.GetString("traceID"),
+	})
+}
@@ -0,0 +1,171 @@
+package task
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type targetProposalResponse struct {
+	Succeed bool                    `json:"succeed"`
+	Data    []models.TargetProposal `json:"data"`
+	TraceID string                  `json:"traceID"`
+}
+
+type acceptTargetsRequest struct {
+	Targets []models.Target `json:"targets"`
+	Replace bool            `json:"replace"` // replace the targets of the task instead of adding to them
+}
+
+type targetListResponse struct {
+	Succeed bool            `json:"succeed"`
+	Data    []models.Target `json:"data"`
+	TraceID string          `json:"traceID"`
+}
+
+// parseTargetCriteria reads the filters, origin and limit query of a target proposal
+func parseTargetCriteria(c *gin.Context) (*models.TargetCriteria, bool) {
+	badRequest := func(msg string) (*models.TargetCriteria, bool) {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   msg,
+			Message: "Wrong Target Criteria",
+			TraceID: c.GetString("traceID"),
+		})
+		return nil, false
+	}
+	criteria := &models.TargetCriteria{}
+	var err error
+	if criteria.MaxDistance, err = strconv.Atoi(c.Query("max_distance")); err != nil {
+		return badRequest("max_distance must be a number")
+	}
+	if criteria.InactiveOnly, err = strconv.ParseBool(c.DefaultQuery("inactive", "false")); err != nil {
+		return badRequest("inactive must be a boolean")
+	}
+	if criteria.MinLoot, err = strconv.ParseInt(c.DefaultQuery("min_loot", "0"), 10, 64); err != nil {
+		return badRequest("min_loot must be a number")
+	}
+	if s := c.Query("has_moon"); s != "" {
+		hasMoon, err := strconv.ParseBool(s)
+		if err != nil {
+			return badRequest("has_moon must be a boolean")
+		}
+		criteria.HasMoon = &hasMoon
+	}
+	if criteria.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "20")); err != nil {
+		return badRequest("limit must be a number")
+	}
+	galaxy, system, planet := c.Query("galaxy"), c.Query("system"), c.Query("planet")
+	if galaxy != "" || system != "" || planet != "" {
+		origin := &models.Target{}
+		if origin.Galaxy, err = strconv.Atoi(galaxy); err != nil {
+			return badRequest("galaxy, system and planet must be numbers")
+		}
+		if origin.System, err = strconv.Atoi(system); err != nil {
+			return badRequest("galaxy, system and planet must be numbers")
+		}
+		if origin.Planet, err = strconv.Atoi(planet); err != nil {
+			return badRequest("galaxy, system and planet must be numbers")
+		}
+		criteria.Origin = origin
+	}
+	return criteria, true
+}
+
+// GetTargetProposals godoc
+// @Summary Propose targets
+// @Description Rank the planets of the scanned universe of the server of the task, most loot of the last spy report first then closest. Distances are measured from the start planet of the task, as known from the last fleet query or stored with the task, unless an origin is given.
+// @Tags task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param max_distance query int true "Largest game distance from the origin, 20000 per galaxy"
+// @Param inactive query bool false "Only inactive players" default(false)
+// @Param min_loot query int false "Least loot of the last spy report, 0 keeps planets never spied" default(0)
+// @Param has_moon query bool false "Only planets with, or without, a moon"
+// @Param limit query int false "Number of proposals, at most 100" default(20)
+// @Param galaxy query int false "Galaxy of the origin, with system and planet"
+// @Param system query int false "System of the origin"
+// @Param planet query int false "Position of the origin"
+// @Success 200 {object} targetProposalResponse "Successful response with ranked proposals"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/target-proposals [get]
+func GetTargetProposals(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	criteria, ok := parseTargetCriteria(c)
+	if !ok {
+		return
+	}
+	proposals, err := taskservice.GetService().ProposeTargets(c, id, criteria)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, targetProposalResponse{
+		Succeed: true,
+		Data:    proposals,
+		TraceID: traceID,
+	})
+}
+
+// AcceptTargets godoc
+// @Summary Accept proposed targets
+// @Description Add targets, usually taken from a proposal, to a task or replace its targets. Positions the task already has are skipped, the start planet is not a target. The task with its new targets must stay valid.
+// @Tags task
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param targets body acceptTargetsRequest true "Targets and whether they replace the current ones"
+// @Success 200 {object} targetListResponse "Successful response with the targets of the task"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/targets [post]
+func AcceptTargets(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	var req acceptTargetsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: "Wrong Request Body",
+			TraceID: traceID,
+		})
+		return
+	}
+	targets, err := taskservice.GetService().AcceptTargets(c, id, req.Targets, req.Replace)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, targetListResponse{
+		Succeed: true,
+		Data:    targets,
+		TraceID: traceID,
+	})
+}
@@ -0,0 +1,223 @@
+package task
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type taskLogListResponse struct {
+	Succeed bool             `json:"succeed"`
+	Data    []models.TaskLog `json:"data"`
+	Total   int64            `json:"total"`
+	TraceID string           `json:"traceID"`
+}
+
+type transportListResponse struct {
+	Succeed bool                     `json:"succeed"`
+	Data    []models.TransportRecord `json:"data"`
+	Total   int64                    `json:"total"`
+	TraceID string                   `json:"traceID"`
+}
+
+const maxLogPageSize = 100
+
+// parseLogFilter reads the filter and pagination query of the log endpoints
+func parseLogFilter(c *gin.Context) (*models.TaskLogFilter, bool) {
+	badRequest := func(msg string) (*models.TaskLogFilter, bool) {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   msg,
+			Message: "Wrong Log Filter",
+			TraceID: c.GetString("traceID"),
+		})
+		return nil, false
+	}
+	filter := &models.TaskLogFilter{}
+	var err error
+	if filter.Page, err = strconv.Atoi(c.DefaultQuery("page", "1")); err != nil || filter.Page < 1 {
+		return badRequest("page must be positive")
+	}
+	if filter.PageSize, err = strconv.Atoi(c.DefaultQuery("page_size", "20")); err != nil ||
+		filter.PageSize < 1 || filter.PageSize > maxLogPageSize {
+		return badRequest("page_size must be between 1 and 100")
+	}
+	if s := c.Query("status"); s != "" {
+		status, err := strconv.Atoi(s)
+		if err != nil {
+			return badRequest("status must be a TASK_RESULT value")
+		}
+		filter.Status = &status
+	}
+	if s := c.Query("task_type"); s != "" {
+		if filter.TaskType, err = strconv.Atoi(s); err != nil {
+			return badRequest("task_type must be a number")
+		}
+	}
+	if s := c.Query("since"); s != "" {
+		if filter.Since, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return badRequest("since must be a Unix timestamp")
+		}
+	}
+	if s := c.Query("until"); s != "" {
+		if filter.Until, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return badRequest("until must be a Unix timestamp")
+		}
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		filter.Asc = true
+	case "desc":
+	default:
+		return badRequest("order must be asc or desc")
+	}
+	return filter, true
+}
+
+// GetTaskLogs godoc
+// @Summary Get task runs
+// @Description Get the runs of a task with their result message and error, newest first by default
+// @Tags task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param status query int false "Result status, 0 running, 1 success, 2 failed, 3 timeout"
+// @Param task_type query int false "Task type"
+// @Param since query int false "Unix timestamp, runs created at or after"
+// @Param until query int false "Unix timestamp, runs created before"
+// @Param order query string false "asc or desc" default(desc)
+// @Param page query int false "Page, from 1" default(1)
+// @Param page_size query int false "Page size, at most 100" default(20)
+// @Success 200 {object} taskLogListResponse "Successful response with task logs"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/logs [get]
+func GetTaskLogs(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseLogFilter(c)
+	if !ok {
+		return
+	}
+	logs, total, err := taskservice.GetService().ListTaskLogs(c, id, filter)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, taskLogListResponse{
+		Succeed: true,
+		Data:    logs,
+		Total:   total,
+		TraceID: traceID,
+	})
+}
+
+// GetAccountLogs godoc
+// @Summary Get account runs
+// @Description Get the runs of every task of an account and its instant requests, newest first by default
+// @Tags account
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param status query int false "Result status, 0 running, 1 success, 2 failed, 3 timeout"
+// @Param task_type query int false "Task type"
+// @Param since query int false "Unix timestamp, runs created at or after"
+// @Param until query int false "Unix timestamp, runs created before"
+// @Param order query string false "asc or desc" default(desc)
+// @Param page query int false "Page, from 1" default(1)
+// @Param page_size query int false "Page size, at most 100" default(20)
+// @Success 200 {object} taskLogListResponse "Successful response with task logs"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /account/{id}/logs [get]
+func GetAccountLogs(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "id must be positive",
+			Message: "Wrong Account ID",
+			TraceID: traceID,
+		})
+		return
+	}
+	filter, ok := parseLogFilter(c)
+	if !ok {
+		return
+	}
+	logs, total, serviceErr := taskservice.GetService().ListAccountLogs(c, uint(id), filter)
+	if serviceErr != nil {
+		c.JSON(serviceErr.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   serviceErr.Error(),
+			Message: serviceErr.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, taskLogListResponse{
+		Succeed: true,
+		Data:    logs,
+		Total:   total,
+		TraceID: traceID,
+	})
+}
+
+// GetTaskTransports godoc
+// @Summary Get transport deliveries
+// @Description Get the resources delivered by the successful runs of a transport task, newest first by default
+// @Tags task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param since query int false "Unix timestamp, deliveries recorded at or after"
+// @Param until query int false "Unix timestamp, deliveries recorded before"
+// @Param order query string false "asc or desc" default(desc)
+// @Param page query int false "Page, from 1" default(1)
+// @Param page_size query int false "Page size, at most 100" default(20)
+// @Success 200 {object} transportListResponse "Successful response with deliveries"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/transports [get]
+func GetTaskTransports(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseLogFilter(c)
+	if !ok {
+		return
+	}
+	records, total, err := taskservice.GetService().ListTransportRecords(c, id, filter)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, transportListResponse{
+		Succeed: true,
+		Data:    records,
+		Total:   total,
+		TraceID: traceID,
+	})
+}
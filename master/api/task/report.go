@@ -0,0 +1,114 @@
+package task
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type battleReportListResponse struct {
+	Succeed bool                  `json:"succeed"`
+	Data    []models.BattleReport `json:"data"`
+	Total   int64                 `json:"total"`
+	TraceID string                `json:"traceID"`
+}
+
+type expeditionReportListResponse struct {
+	Succeed bool                      `json:"succeed"`
+	Data    []models.ExpeditionReport `json:"data"`
+	Total   int64                     `json:"total"`
+	TraceID string                    `json:"traceID"`
+}
+
+// GetTaskBattleReports godoc
+// @Summary Get battle reports
+// @Description Get the battle reports of the runs of an attack task, newest first by default
+// @Tags task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param since query int false "Unix timestamp, reports recorded at or after"
+// @Param until query int false "Unix timestamp, reports recorded before"
+// @Param order query string false "asc or desc" default(desc)
+// @Param page query int false "Page, from 1" default(1)
+// @Param page_size query int false "Page size, at most 100" default(20)
+// @Success 200 {object} battleReportListResponse "Successful response with battle reports"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/battle-reports [get]
+func GetTaskBattleReports(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseLogFilter(c)
+	if !ok {
+		return
+	}
+	reports, total, err := taskservice.GetService().ListBattleReports(c, id, filter)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, battleReportListResponse{
+		Succeed: true,
+		Data:    reports,
+		Total:   total,
+		TraceID: traceID,
+	})
+}
+
+// GetTaskExpeditionReports godoc
+// @Summary Get expedition reports
+// @Description Get the findings of the runs of an explore task, newest first by default
+// @Tags task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param since query int false "Unix timestamp, reports recorded at or after"
+// @Param until query int false "Unix timestamp, reports recorded before"
+// @Param order query string false "asc or desc" default(desc)
+// @Param page query int false "Page, from 1" default(1)
+// @Param page_size query int false "Page size, at most 100" default(20)
+// @Success 200 {object} expeditionReportListResponse "Successful response with expedition reports"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/expedition-reports [get]
+func GetTaskExpeditionReports(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseLogFilter(c)
+	if !ok {
+		return
+	}
+	reports, total, err := taskservice.GetService().ListExpeditionReports(c, id, filter)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, expeditionReportListResponse{
+		Succeed: true,
+		Data:    reports,
+		Total:   total,
+		TraceID: traceID,
+	})
+}
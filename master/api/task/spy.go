@@ -0,0 +1,167 @@
+package task
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type spyReportListResponse struct {
+	Succeed bool               `json:"succeed"`
+	Data    []models.SpyReport `json:"data"`
+	Total   int64              `json:"total"`
+	TraceID string             `json:"traceID"`
+}
+
+type spyReportResponse struct {
+	Succeed bool              `json:"succeed"`
+	Data    *models.SpyReport `json:"data"`
+	TraceID string            `json:"traceID"`
+}
+
+// parseSpyReportFilter reads the coordinates, time range and pagination query of the spy report list
+func parseSpyReportFilter(c *gin.Context) (*models.SpyReportFilter, bool) {
+	badRequest := func(msg string) (*models.SpyReportFilter, bool) {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   msg,
+			Message: "Wrong Spy Report Filter",
+			TraceID: c.GetString("traceID"),
+		})
+		return nil, false
+	}
+	filter := &models.SpyReportFilter{}
+	var err error
+	if filter.Page, err = strconv.Atoi(c.DefaultQuery("page", "1")); err != nil || filter.Page < 1 {
+		return badRequest("page must be positive")
+	}
+	if filter.PageSize, err = strconv.Atoi(c.DefaultQuery("page_size", "20")); err != nil ||
+		filter.PageSize < 1 || filter.PageSize > maxLogPageSize {
+		return badRequest("page_size must be between 1 and 100")
+	}
+	galaxy, system, planet := c.Query("galaxy"), c.Query("system"), c.Query("planet")
+	if galaxy != "" || system != "" || planet != "" {
+		target := &models.Target{}
+		if target.Galaxy, err = strconv.Atoi(galaxy); err != nil {
+			return badRequest("galaxy, system and planet must be numbers")
+		}
+		if target.System, err = strconv.Atoi(system); err != nil {
+			return badRequest("galaxy, system and planet must be numbers")
+		}
+		if target.Planet, err = strconv.Atoi(planet); err != nil {
+			return badRequest("galaxy, system and planet must be numbers")
+		}
+		if target.Is_moon, err = strconv.ParseBool(c.DefaultQuery("is_moon", "false")); err != nil {
+			return badRequest("is_moon must be a boolean")
+		}
+		filter.Target = target
+	}
+	if s := c.Query("since"); s != "" {
+		if filter.Since, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return badRequest("since must be a Unix timestamp")
+		}
+	}
+	if s := c.Query("until"); s != "" {
+		if filter.Until, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return badRequest("until must be a Unix timestamp")
+		}
+	}
+	return filter, true
+}
+
+// GetAccountSpyReports godoc
+// @Summary Get spy reports
+// @Description Get the spy reports of an account, newest first, optionally only those about one planet
+// @Tags account
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param galaxy query int false "Galaxy of the spied planet, with system and planet"
+// @Param system query int false "System of the spied planet"
+// @Param planet query int false "Position of the spied planet"
+// @Param is_moon query bool false "The spied planet is a moon" default(false)
+// @Param since query int false "Unix timestamp, reports at or after"
+// @Param until query int false "Unix timestamp, reports before"
+// @Param page query int false "Page, from 1" default(1)
+// @Param page_size query int false "Page size, at most 100" default(20)
+// @Success 200 {object} spyReportListResponse "Successful response with spy reports"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /account/{id}/spy-reports [get]
+func GetAccountSpyReports(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "id must be positive",
+			Message: "Wrong Account ID",
+			TraceID: traceID,
+		})
+		return
+	}
+	filter, ok := parseSpyReportFilter(c)
+	if !ok {
+		return
+	}
+	reports, total, serviceErr := taskservice.GetService().ListSpyReports(c, uint(id), filter)
+	if serviceErr != nil {
+		c.JSON(serviceErr.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   serviceErr.Error(),
+			Message: serviceErr.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, spyReportListResponse{
+		Succeed: true,
+		Data:    reports,
+		Total:   total,
+		TraceID: traceID,
+	})
+}
+
+// GetSpyReport godoc
+// @Summary Get a spy report
+// @Tags task
+// @Produce json
+// @Param id path int true "Spy report ID"
+// @Success 200 {object} spyReportResponse "Successful response with the spy report"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /spy-report/{id} [get]
+func GetSpyReport(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "id must be positive",
+			Message: "Wrong Spy Report ID",
+			TraceID: traceID,
+		})
+		return
+	}
+	report, serviceErr := taskservice.GetService().GetSpyReport(c, uint(id))
+	if serviceErr != nil {
+		c.JSON(serviceErr.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   serviceErr.Error(),
+			Message: serviceErr.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, spyReportResponse{
+		Succeed: true,
+		Data:    report,
+		TraceID: traceID,
+	})
+}
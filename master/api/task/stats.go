@@ -0,0 +1,125 @@
+package task
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type taskStatsResponse struct {
+	Succeed bool                    `json:"succeed"`
+	Data    *models.TaskStatsReport `json:"data"`
+	TraceID string                  `json:"traceID"`
+}
+
+// parseStatsRange reads the since and until query, 0 when omitted
+func parseStatsRange(c *gin.Context) (since, until int64, ok bool) {
+	var err1, err2 error
+	if s := c.Query("since"); s != "" {
+		since, err1 = strconv.ParseInt(s, 10, 64)
+	}
+	if s := c.Query("until"); s != "" {
+		until, err2 = strconv.ParseInt(s, 10, 64)
+	}
+	if err1 != nil || err2 != nil || since < 0 || until < 0 || (until != 0 && since >= until) {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "since and until must be Unix timestamps and since before until",
+			Message: "Wrong Time Range",
+			TraceID: c.GetString("traceID"),
+		})
+		return 0, 0, false
+	}
+	return since, until, true
+}
+
+// GetTaskStats godoc
+// @Summary Get task statistics
+// @Description Get dispatch counts, success and failure rates, mean round trip and failure reasons of a task, in total and per day
+// @Tags task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param since query int false "Unix timestamp, 30 days before until when omitted"
+// @Param until query int false "Unix timestamp, now when omitted"
+// @Success 200 {object} taskStatsResponse "Successful response with statistics"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/stats [get]
+func GetTaskStats(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	since, until, ok := parseStatsRange(c)
+	if !ok {
+		return
+	}
+	report, err := taskservice.GetService().GetTaskStats(c, id, since, until)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, taskStatsResponse{
+		Succeed: true,
+		Data:    report,
+		TraceID: traceID,
+	})
+}
+
+// GetAccountStats godoc
+// @Summary Get account statistics
+// @Description Get dispatch counts, success and failure rates, mean round trip and failure reasons of an account, in total and per day
+// @Tags account
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param since query int false "Unix timestamp, 30 days before until when omitted"
+// @Param until query int false "Unix timestamp, now when omitted"
+// @Success 200 {object} taskStatsResponse "Successful response with statistics"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /account/{id}/stats [get]
+func GetAccountStats(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "id must be positive",
+			Message: "Wrong Account ID",
+			TraceID: traceID,
+		})
+		return
+	}
+	since, until, ok := parseStatsRange(c)
+	if !ok {
+		return
+	}
+	report, serviceErr := taskservice.GetService().GetAccountStats(c, uint(id), since, until)
+	if serviceErr != nil {
+		c.JSON(serviceErr.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   serviceErr.Error(),
+			Message: serviceErr.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, taskStatsResponse{
+		Succeed: true,
+		Data:    report,
+		TraceID: traceID,
+	})
+}
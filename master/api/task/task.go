@@ -248,3 +248,228 @@ func DeleteTask(c *gin.Context) {
 		TraceID: traceID,
 	})
 }
+
+type runTaskResponse struct {
+	Succeed bool   `json:"succeed"`
+	UUID    string `json:"uuid"`
+	TraceID string `json:"traceID"`
+}
+
+func parseTaskID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		msg := "id must be positive"
+		if err != nil {
+			msg = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   msg,
+			Message: "Wrong Task ID",
+			TraceID: c.GetString("traceID"),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// PauseTask godoc
+// @Summary Pause a task
+// @Description Stop dispatching a task until it is resumed
+// @Tags task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} taskResponse "Successful response with task data"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/pause [post]
+func PauseTask(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	task, err := taskservice.GetService().PauseTask(c, id)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, taskResponse{
+		Succeed: true,
+		Data:    task.ToDTO(),
+		TraceID: traceID,
+	})
+}
+
+// ResumeTask godoc
+// @Summary Resume a task
+// @Description Enable a paused, auto-disabled or insufficient fleet task and reset its failure count
+// @Tags task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} taskResponse "Successful response with task data"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/resume [post]
+func ResumeTask(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	task, err := taskservice.GetService().ResumeTask(c, id)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, taskResponse{
+		Succeed: true,
+		Data:    task.ToDTO(),
+		TraceID: traceID,
+	})
+}
+
+// RunTaskNow godoc
+// @Summary Run a task now
+// @Description Dispatch a task immediately through the instant queue
+// @Tags task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} runTaskResponse "Successful response with the run uuid"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 409 {object} api.ErrorResponse "Task already running or completed"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/run [post]
+func RunTaskNow(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	uuid, err := taskservice.GetService().RunTaskNow(c, id)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, runTaskResponse{
+		Succeed: true,
+		UUID:    uuid,
+		TraceID: traceID,
+	})
+}
+
+type taskPreviewResponse struct {
+	Succeed bool                  `json:"succeed"`
+	Data    []*models.TaskPreview `json:"data"`
+	TraceID string                `json:"traceID"`
+}
+
+// PreviewTasks godoc
+// @Summary Preview the task generator
+// @Description Show per task whether the generator would dispatch it, the target, the delay and the skip reason. Nothing is written or queued.
+// @Tags task
+// @Produce json
+// @Param account_id query int false "Account ID, all accounts of the user when omitted"
+// @Success 200 {object} taskPreviewResponse "Successful response with task previews"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/preview [get]
+func PreviewTasks(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	accountID := 0
+	if idStr := c.Query("account_id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id <= 0 {
+			msg := "account_id must be positive"
+			if err != nil {
+				msg = err.Error()
+			}
+			c.JSON(http.StatusBadRequest, api.ErrorResponse{
+				Succeed: false,
+				Error:   msg,
+				Message: "Wrong Account ID",
+				TraceID: traceID,
+			})
+			return
+		}
+		accountID = id
+	}
+	previews, err := taskservice.GetService().PreviewTasks(c, uint(accountID))
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, taskPreviewResponse{
+		Succeed: true,
+		Data:    previews,
+		TraceID: traceID,
+	})
+}
+
+type taskHistoryResponse struct {
+	Succeed bool                    `json:"succeed"`
+	Data    []models.TaskTransition `json:"data"`
+	TraceID string                  `json:"traceID"`
+}
+
+// GetTaskHistory godoc
+// @Summary Get task status history
+// @Description Get the status transitions of a task, oldest first
+// @Tags task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} taskHistoryResponse "Successful response with transitions"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/{id}/history [get]
+func GetTaskHistory(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+	transitions, err := taskservice.GetService().GetTaskHistory(c, id)
+	if err != nil {
+		c.JSON(err.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: err.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, taskHistoryResponse{
+		Succeed: true,
+		Data:    transitions,
+		TraceID: traceID,
+	})
+}
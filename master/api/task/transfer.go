@@ -0,0 +1,164 @@
+package task
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type taskImportResponse struct {
+	Succeed bool                     `json:"succeed"`
+	Data    *models.TaskImportResult `json:"data"`
+	TraceID string                   `json:"traceID"`
+}
+
+const maxTaskDocumentSize = 1 << 20
+
+// parseTransferQuery reads the account_id and format query of import and export
+func parseTransferQuery(c *gin.Context) (uint, string, bool) {
+	traceID := c.GetString("traceID")
+	accountID, err := strconv.Atoi(c.Query("account_id"))
+	if err != nil || accountID <= 0 {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "account_id must be positive",
+			Message: "Wrong Account ID",
+			TraceID: traceID,
+		})
+		return 0, "", false
+	}
+	format := c.DefaultQuery("format", models.TASK_DOCUMENT_YAML)
+	if format != models.TASK_DOCUMENT_YAML && format != models.TASK_DOCUMENT_JSON {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "format must be yaml or json",
+			Message: "Wrong Format",
+			TraceID: traceID,
+		})
+		return 0, "", false
+	}
+	return uint(accountID), format, true
+}
+
+// ExportTasks godoc
+// @Summary Export tasks
+// @Description Download the tasks of an account with their targets, fleet and schedule as a YAML or JSON document
+// @Tags task
+// @Produce json
+// @Produce application/x-yaml
+// @Param account_id query int true "Account ID"
+// @Param format query string false "yaml or json" default(yaml)
+// @Success 200 {object} models.TaskDocument "Task document"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/export [get]
+func ExportTasks(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	accountID, format, ok := parseTransferQuery(c)
+	if !ok {
+		return
+	}
+	doc, serviceErr := taskservice.GetService().ExportTasks(c, accountID)
+	if serviceErr != nil {
+		c.JSON(serviceErr.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   serviceErr.Error(),
+			Message: serviceErr.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	body, err := doc.Encode(format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: "Encode Document Error",
+			TraceID: traceID,
+		})
+		return
+	}
+	contentType := "application/json"
+	if format == models.TASK_DOCUMENT_YAML {
+		contentType = "application/x-yaml"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=tasks-%d.%s", accountID, format))
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// ImportTasks godoc
+// @Summary Import tasks
+// @Description Create or update the tasks of an account from a YAML or JSON document, matched by name. The document is validated as a whole and applied atomically, dry_run only returns the changes.
+// @Tags task
+// @Accept json
+// @Accept application/x-yaml
+// @Produce json
+// @Param account_id query int true "Account ID"
+// @Param format query string false "yaml or json" default(yaml)
+// @Param dry_run query bool false "Only return the changes" default(false)
+// @Param document body models.TaskDocument true "Task document"
+// @Success 200 {object} taskImportResponse "Successful response with the changes"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 409 {object} api.ErrorResponse "Task names of the account are ambiguous"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /task/import [post]
+func ImportTasks(c *gin.Context) {
+	traceID := c.GetString("traceID")
+	accountID, format, ok := parseTransferQuery(c)
+	if !ok {
+		return
+	}
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: "Wrong Dry Run",
+			TraceID: traceID,
+		})
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxTaskDocumentSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: "Read Document Error",
+			TraceID: traceID,
+		})
+		return
+	}
+	doc, err := models.ParseTaskDocument(body, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: "Invalid Task Document",
+			TraceID: traceID,
+		})
+		return
+	}
+	result, serviceErr := taskservice.GetService().ImportTasks(c, accountID, doc, dryRun)
+	if serviceErr != nil {
+		c.JSON(serviceErr.StatusCode(), api.ErrorResponse{
+			Succeed: false,
+			Error:   serviceErr.Error(),
+			Message: serviceErr.Msg(),
+			TraceID: traceID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, taskImportResponse{
+		Succeed: true,
+		Data:    result,
+		TraceID: traceID,
+	})
+}
@@ -0,0 +1,288 @@
+package template
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"GalaxyEmpireWeb/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type templateRequest struct {
+	Name string          `json:"name"`
+	Spec models.TaskSpec `json:"spec"`
+}
+
+type instantiateRequest struct {
+	Instances []models.TemplateInstance `json:"instances"`
+}
+
+type templateResponse struct {
+	Succeed bool                 `json:"succeed"`
+	Data    *models.TaskTemplate `json:"data"`
+	TraceID string               `json:"traceID"`
+}
+
+type templateListResponse struct {
+	Succeed bool                  `json:"succeed"`
+	Data    []models.TaskTemplate `json:"data"`
+	TraceID string                `json:"traceID"`
+}
+
+type instantiateResponse struct {
+	Succeed bool              `json:"succeed"`
+	Data    []*models.TaskDTO `json:"data"`
+	TraceID string            `json:"traceID"`
+}
+
+type applyResponse struct {
+	Succeed bool                      `json:"succeed"`
+	Data    []models.TaskImportChange `json:"data"`
+	TraceID string                    `json:"traceID"`
+}
+
+func parseTemplateID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   "id must be positive",
+			Message: "Wrong Template ID",
+			TraceID: c.GetString("traceID"),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func bindBody(c *gin.Context, body interface{}) bool {
+	if err := c.ShouldBindJSON(body); err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{
+			Succeed: false,
+			Error:   err.Error(),
+			Message: "Wrong Request Body",
+			TraceID: c.GetString("traceID"),
+		})
+		return false
+	}
+	return true
+}
+
+func serviceError(c *gin.Context, err *utils.ServiceError) {
+	c.JSON(err.StatusCode(), api.ErrorResponse{
+		Succeed: false,
+		Error:   err.Error(),
+		Message: err.Msg(),
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// CreateTemplate godoc
+// @Summary Create a task template
+// @Description Save a task configuration that can be instantiated on several accounts
+// @Tags template
+// @Accept json
+// @Produce json
+// @Param template body templateRequest true "Template name and task spec"
+// @Success 200 {object} templateResponse "Successful response with the template"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /template [post]
+func CreateTemplate(c *gin.Context) {
+	var req templateRequest
+	if !bindBody(c, &req) {
+		return
+	}
+	template := &models.TaskTemplate{Name: req.Name, Spec: req.Spec}
+	if err := taskservice.GetService().CreateTemplate(c, template); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, templateResponse{
+		Succeed: true,
+		Data:    template,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// ListTemplates godoc
+// @Summary List task templates
+// @Description List the task templates of the current user
+// @Tags template
+// @Produce json
+// @Success 200 {object} templateListResponse "Successful response with templates"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /template [get]
+func ListTemplates(c *gin.Context) {
+	templates, err := taskservice.GetService().ListTemplates(c)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, templateListResponse{
+		Succeed: true,
+		Data:    templates,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// GetTemplate godoc
+// @Summary Get a task template
+// @Tags template
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} templateResponse "Successful response with the template"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /template/{id} [get]
+func GetTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+	template, err := taskservice.GetService().GetTemplate(c, id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, templateResponse{
+		Succeed: true,
+		Data:    template,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// UpdateTemplate godoc
+// @Summary Update a task template
+// @Description Replace the name and spec of a template and bump its version. Instances change on apply.
+// @Tags template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param template body templateRequest true "Template name and task spec"
+// @Success 200 {object} templateResponse "Successful response with the template"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /template/{id} [put]
+func UpdateTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+	var req templateRequest
+	if !bindBody(c, &req) {
+		return
+	}
+	template, err := taskservice.GetService().UpdateTemplate(c, id, &models.TaskTemplate{Name: req.Name, Spec: req.Spec})
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, templateResponse{
+		Succeed: true,
+		Data:    template,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// DeleteTemplate godoc
+// @Summary Delete a task template
+// @Description Delete a template, its instances keep running as plain tasks
+// @Tags template
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} templateResponse "Successful response"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /template/{id} [delete]
+func DeleteTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+	if err := taskservice.GetService().DeleteTemplate(c, id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, templateResponse{
+		Succeed: true,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// InstantiateTemplate godoc
+// @Summary Instantiate a task template
+// @Description Create one task per account from the template, each starting from its own planet. Either every task is created or none.
+// @Tags template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param instances body instantiateRequest true "Accounts and start planets"
+// @Success 200 {object} instantiateResponse "Successful response with the created tasks"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /template/{id}/instantiate [post]
+func InstantiateTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+	var req instantiateRequest
+	if !bindBody(c, &req) {
+		return
+	}
+	tasks, err := taskservice.GetService().InstantiateTemplate(c, id, req.Instances)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	data := make([]*models.TaskDTO, 0, len(tasks))
+	for _, task := range tasks {
+		data = append(data, task.ToDTO())
+	}
+	c.JSON(http.StatusOK, instantiateResponse{
+		Succeed: true,
+		Data:    data,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// ApplyTemplate godoc
+// @Summary Apply a template update to its instances
+// @Description Bring every instance built from an older template version up to date. The start planet, runtime state and enabled flag of each instance are kept.
+// @Tags template
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} applyResponse "Successful response with the change of every instance"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /template/{id}/apply [post]
+func ApplyTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+	changes, err := taskservice.GetService().ApplyTemplate(c, id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, applyResponse{
+		Succeed: true,
+		Data:    changes,
+		TraceID: c.GetString("traceID"),
+	})
+}
@@ -0,0 +1,221 @@
+package universe
+
+import (
+	"GalaxyEmpireWeb/api"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/services/taskservice"
+	"GalaxyEmpireWeb/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxPageSize = 100
+
+type scanResponse struct {
+	Succeed bool   `json:"succeed"`
+	Data    string `json:"data"` // uuid of the scan
+	TraceID string `json:"traceID"`
+}
+
+type universeListResponse struct {
+	Succeed bool                    `json:"succeed"`
+	Data    []models.UniversePlanet `json:"data"`
+	Total   int64                   `json:"total"`
+	TraceID string                  `json:"traceID"`
+}
+
+func badRequest(c *gin.Context, err error, msg string) {
+	c.JSON(http.StatusBadRequest, api.ErrorResponse{
+		Succeed: false,
+		Error:   err.Error(),
+		Message: msg,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+func serviceError(c *gin.Context, err *utils.ServiceError) {
+	c.JSON(err.StatusCode(), api.ErrorResponse{
+		Succeed: false,
+		Error:   err.Error(),
+		Message: err.Msg(),
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// parsePositive reads a positive path parameter
+func parsePositive(c *gin.Context, name string) (int, bool) {
+	value, err := strconv.Atoi(c.Param(name))
+	if err != nil || value <= 0 {
+		badRequest(c, errors.New(name+" must be positive"), "Wrong Universe Position")
+		return 0, false
+	}
+	return value, true
+}
+
+// parseUniverseFilter reads the system range, player filters and pagination query of the galaxy view
+func parseUniverseFilter(c *gin.Context, galaxy int) (*models.UniverseFilter, bool) {
+	filter := &models.UniverseFilter{
+		Galaxy:   galaxy,
+		Player:   c.Query("player"),
+		Alliance: c.Query("alliance"),
+	}
+	var err error
+	if filter.Page, err = strconv.Atoi(c.DefaultQuery("page", "1")); err != nil || filter.Page < 1 {
+		badRequest(c, errors.New("page must be positive"), "Wrong Universe Filter")
+		return nil, false
+	}
+	if filter.PageSize, err = strconv.Atoi(c.DefaultQuery("page_size", "20")); err != nil ||
+		filter.PageSize < 1 || filter.PageSize > maxPageSize {
+		badRequest(c, errors.New("page_size must be between 1 and 100"), "Wrong Universe Filter")
+		return nil, false
+	}
+	if s := c.Query("system_from"); s != "" {
+		if filter.SystemFrom, err = strconv.Atoi(s); err != nil || filter.SystemFrom < 1 {
+			badRequest(c, errors.New("system_from must be positive"), "Wrong Universe Filter")
+			return nil, false
+		}
+	}
+	if s := c.Query("system_to"); s != "" {
+		if filter.SystemTo, err = strconv.Atoi(s); err != nil || filter.SystemTo < 1 {
+			badRequest(c, errors.New("system_to must be positive"), "Wrong Universe Filter")
+			return nil, false
+		}
+	}
+	if filter.Inactive, err = strconv.ParseBool(c.DefaultQuery("inactive", "false")); err != nil {
+		badRequest(c, errors.New("inactive must be a boolean"), "Wrong Universe Filter")
+		return nil, false
+	}
+	return filter, true
+}
+
+// ScanGalaxy godoc
+// @Summary Scan a galaxy
+// @Description Ask a node to walk a range of systems with the account. The occupied positions found replace those systems in the universe catalog of the server of the account.
+// @Tags universe
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param range body models.ScanRange true "Galaxy and systems to scan, at most 100 systems"
+// @Success 200 {object} scanResponse "Successful response with the uuid of the scan"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /account/{id}/universe/scan [post]
+func ScanGalaxy(c *gin.Context) {
+	id, ok := parsePositive(c, "id")
+	if !ok {
+		return
+	}
+	var scanRange models.ScanRange
+	if err := c.ShouldBindJSON(&scanRange); err != nil {
+		badRequest(c, err, "Wrong Request Body")
+		return
+	}
+	uuid, err := taskservice.GetService().ScanGalaxy(c, uint(id), scanRange)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, scanResponse{
+		Succeed: true,
+		Data:    uuid,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// GetGalaxy godoc
+// @Summary Browse a galaxy
+// @Description Get the known occupied positions of a galaxy of the server of the account, ordered by system and position
+// @Tags universe
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param galaxy path int true "Galaxy"
+// @Param system_from query int false "First system"
+// @Param system_to query int false "Last system"
+// @Param player query string false "Player name"
+// @Param alliance query string false "Alliance tag"
+// @Param inactive query bool false "Only inactive players" default(false)
+// @Param page query int false "Page, from 1" default(1)
+// @Param page_size query int false "Page size, at most 100" default(20)
+// @Success 200 {object} universeListResponse "Successful response with planets"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /account/{id}/universe/{galaxy} [get]
+func GetGalaxy(c *gin.Context) {
+	id, ok := parsePositive(c, "id")
+	if !ok {
+		return
+	}
+	galaxy, ok := parsePositive(c, "galaxy")
+	if !ok {
+		return
+	}
+	filter, ok := parseUniverseFilter(c, galaxy)
+	if !ok {
+		return
+	}
+	planets, total, err := taskservice.GetService().ListUniverse(c, uint(id), filter)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, universeListResponse{
+		Succeed: true,
+		Data:    planets,
+		Total:   total,
+		TraceID: c.GetString("traceID"),
+	})
+}
+
+// GetSystem godoc
+// @Summary Browse a system
+// @Description Get the known occupied positions of one system of the server of the account
+// @Tags universe
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param galaxy path int true "Galaxy"
+// @Param system path int true "System"
+// @Success 200 {object} universeListResponse "Successful response with planets"
+// @Failure 400 {object} api.ErrorResponse "Bad Request with error message"
+// @Failure 403 {object} api.ErrorResponse "Forbidden with error message"
+// @Failure 404 {object} api.ErrorResponse "Not Found with error message"
+// @Failure 500 {object} api.ErrorResponse "Internal Server Error with error message"
+// @Router /account/{id}/universe/{galaxy}/{system} [get]
+func GetSystem(c *gin.Context) {
+	id, ok := parsePositive(c, "id")
+	if !ok {
+		return
+	}
+	galaxy, ok := parsePositive(c, "galaxy")
+	if !ok {
+		return
+	}
+	system, ok := parsePositive(c, "system")
+	if !ok {
+		return
+	}
+	filter := &models.UniverseFilter{
+		Galaxy:     galaxy,
+		SystemFrom: system,
+		SystemTo:   system,
+		Page:       1,
+		PageSize:   maxPageSize, // a system has far fewer positions
+	}
+	planets, total, err := taskservice.GetService().ListUniverse(c, uint(id), filter)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, universeListResponse{
+		Succeed: true,
+		Data:    planets,
+		Total:   total,
+		TraceID: c.GetString("traceID"),
+	})
+}
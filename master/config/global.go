@@ -6,7 +6,26 @@ var TASK_GENERATOR_INTERVAL = time.Second * 30
 var TASK_QUEUE_NAME = "task_queue"
 var INSTANT_QUEUE_NAME = "instant_queue"
 var RESULT_QUEUE_NAME = "result_queue"
+var RESULT_WORKERS = 8                   // result handlers running at once, results of one task share a worker
+var RESULT_WORKER_QUEUE = 4              // results waiting per worker before the consumer blocks
+var RESULT_PREFETCH = 32                 // unacked results RabbitMQ hands to this process
+var RESULT_DLQ_NAME = "result_queue.dlq" // results that could not be applied, archived in dead_letters
+var RESULT_MAX_ATTEMPTS = 3              // tries of a result failing with a transient error before it is dead-lettered
+var RESULT_RETRY_DELAY = time.Second     // wait before the second try, growing with each further try
 var DELAYED_EXCHANGE_NAME = "delayed_exchange"
-var TASK_DELAY = int64(5)           // seconds
-var FAILED_TASK_DELAY = int64(3600) // seconds
+var TASK_DELAY = int64(5)                  // seconds
+var FAILED_TASK_DELAY = int64(300)         // seconds before the first retry, doubled on each further failure
+var FAILED_TASK_BACKOFF_MAX = int64(14400) // seconds, the delay stops doubling at four hours
+var FAILED_TASK_DISABLE_AFTER = 11         // consecutive failures, about a day of retries
 var QUEUE_THRESHOLD = time.Minute * 60
+var FLEET_TASK_TIMEOUT = time.Hour * 4     // after the launch, also used for logs without deadline
+var INSTANT_TASK_TIMEOUT = time.Minute * 5 // login and planet queries
+var SCAN_TASK_TIMEOUT = time.Minute * 30   // galaxy scans, one page load per system
+var SCAN_MAX_SYSTEMS = 100                 // systems one galaxy scan may walk
+var TASK_REAPER_INTERVAL = time.Minute     // how often overdue task logs are timed out
+var LEADER_LEASE_KEY = "galaxy:task_generator:leader"
+var LEADER_LEASE_TTL = time.Second * 15                     // a dead leader is replaced within this time
+var SCHEDULE_INDEX_KEY = "galaxy:task_schedule"             // sorted set of task ids scored by due time
+var SCHEDULE_WAKEUP_CHANNEL = "galaxy:task_schedule:wakeup" // published when the index changes
+var SCHEDULE_RESYNC_INTERVAL = time.Minute * 10             // full rebuild of the index from the DB
+var SCHEDULE_MIN_INTERVAL = time.Second                     // pause between two scheduler passes
@@ -26,7 +26,7 @@ func servicesInit(db *gorm.DB, rdb *r.Client, mq *queue.RabbitMQConnection) {
 	}
 	userservice.InitService(db, enforcer)
 	accountservice.InitService(db, enforcer)
-	taskservice.InitService(db, mq, enforcer)
+	taskservice.InitService(db, rdb, mq, enforcer)
 }
 
 var rdb *r.Client
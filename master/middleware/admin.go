@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"GalaxyEmpireWeb/api"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminMiddleware only lets admins (role 1) through, it runs after JWTAuthMiddleware
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetInt("role") != 1 {
+			log.Warn("[middleware]AdminMiddleware forbidden",
+				zap.String("traceID", c.GetString("traceID")),
+				zap.Uint("UserID", c.GetUint("userID")))
+			c.AbortWithStatusJSON(http.StatusForbidden, api.ErrorResponse{
+				Succeed: false,
+				Error:   "Forbidden",
+				Message: "Admin only",
+				TraceID: c.GetString("traceID"),
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// CargoManifest is the resource payload of a transport run
+type CargoManifest struct {
+	Metal     int64 `json:"metal" yaml:"metal"`
+	Crystal   int64 `json:"crystal" yaml:"crystal"`
+	Deuterium int64 `json:"deuterium" yaml:"deuterium"`
+	All       bool  `json:"all" yaml:"all"` // load every resource of the start planet the ships can carry
+}
+
+// IsEmpty reports whether the manifest carries nothing
+func (m CargoManifest) IsEmpty() bool {
+	return !m.All && m.Metal == 0 && m.Crystal == 0 && m.Deuterium == 0
+}
+
+func (m CargoManifest) Validate() error {
+	if m.Metal < 0 || m.Crystal < 0 || m.Deuterium < 0 {
+		return errors.New("cargo amounts must not be negative")
+	}
+	if m.All && (m.Metal != 0 || m.Crystal != 0 || m.Deuterium != 0) {
+		return errors.New("cargo amounts must be empty when all is set")
+	}
+	return nil
+}
+
+// TransportRecord is the delivery of one successful transport run
+type TransportRecord struct {
+	gorm.Model
+	TaskID    uint   `json:"task_id" gorm:"index"`
+	AccountID uint   `json:"account_id" gorm:"index"`
+	UUID      string `json:"uuid" gorm:"unique"`
+	TargetID  uint   `json:"target_id"`
+	Metal     int64  `json:"metal"`
+	Crystal   int64  `json:"crystal"`
+	Deuterium int64  `json:"deuterium"`
+	// Unix timestamp the fleet came back, reported by the node
+	BackTimestamp int64 `json:"back_timestamp"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "testing"
+
+func TestCargoManifestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cargo   CargoManifest
+		wantErr bool
+	}{
+		{"empty", CargoManifest{}, false},
+		{"amounts", CargoManifest{Metal: 1000, Deuterium: 50}, false},
+		{"all", CargoManifest{All: true}, false},
+		{"negative", CargoManifest{Crystal: -1}, true},
+		{"all with amounts", CargoManifest{All: true, Metal: 10}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.cargo.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeadLetter is a queue message that could not be processed, archived from the dead-letter queue
+type DeadLetter struct {
+	gorm.Model
+	Queue       string     `json:"queue"` // queue the message was consumed from
+	UUID        string     `json:"uuid" gorm:"index"`
+	TaskID      uint       `json:"task_id"`
+	Reason      string     `json:"reason"`
+	Body        string     `json:"body" gorm:"type:text"`
+	ReplayCount int        `json:"replay_count"`
+	ReplayedAt  *time.Time `json:"replayed_at"`
+}
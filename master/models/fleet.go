@@ -19,17 +19,17 @@ type Fleet struct {
 }
 
 type FleetDTO struct {
-	LightFighter int `json:"lf"`
-	HeavyFighter int `json:"hf"`
-	Cruiser      int `json:"cr"`
-	Battleship   int `json:"bs"`
-	Dreadnought  int `json:"dr"`
-	Destroyer    int `json:"de"`
-	Deathstar    int `json:"ds"`
-	Bomber       int `json:"bomb"`
-	Guardian     int `json:"guard"`
-	Satellite    int `json:"satellite"`
-	Cargo        int `json:"cargo"`
+	LightFighter int `json:"lf" yaml:"lf"`
+	HeavyFighter int `json:"hf" yaml:"hf"`
+	Cruiser      int `json:"cr" yaml:"cr"`
+	Battleship   int `json:"bs" yaml:"bs"`
+	Dreadnought  int `json:"dr" yaml:"dr"`
+	Destroyer    int `json:"de" yaml:"de"`
+	Deathstar    int `json:"ds" yaml:"ds"`
+	Bomber       int `json:"bomb" yaml:"bomb"`
+	Guardian     int `json:"guard" yaml:"guard"`
+	Satellite    int `json:"satellite" yaml:"satellite"`
+	Cargo        int `json:"cargo" yaml:"cargo"`
 }
 
 func (fleet Fleet) ToDTO() *FleetDTO {
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// FleetInventory is the last known ship count of one planet of an account,
+// written by the query fleet task
+type FleetInventory struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	AccountID uint      `json:"account_id" gorm:"uniqueIndex:idx_fleet_inventory_planet"`
+	PlanetID  uint      `json:"planet_id" gorm:"uniqueIndex:idx_fleet_inventory_planet"` // game planet id, as Task.StartPlanetID
+	Position  string    `json:"position"`                                                // galaxy:system:planet:is_moon
+	Ships     FleetDTO  `json:"ships" gorm:"embedded"`
+	UUID      string    `json:"uuid"` // query that reported it
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PlanetFleet is the ship count of one planet in a query fleet result
+type PlanetFleet struct {
+	PlanetID uint     `json:"planet_id"`
+	Position string   `json:"position"`
+	Fleet    FleetDTO `json:"fleet"`
+}
+
+// FleetQueryResult is the msg of a successful query fleet result
+type FleetQueryResult struct {
+	Planets []PlanetFleet `json:"planets"`
+}
+
+type shipCount struct {
+	name  string
+	count int
+}
+
+// counts lists the ship counts by json name, in a fixed order
+func (f FleetDTO) counts() []shipCount {
+	return []shipCount{
+		{"lf", f.LightFighter},
+		{"hf", f.HeavyFighter},
+		{"cr", f.Cruiser},
+		{"bs", f.Battleship},
+		{"dr", f.Dreadnought},
+		{"de", f.Destroyer},
+		{"ds", f.Deathstar},
+		{"bomb", f.Bomber},
+		{"guard", f.Guardian},
+		{"satellite", f.Satellite},
+		{"cargo", f.Cargo},
+	}
+}
+
+// Shortage lists the ships of f missing from available as "name needed/available",
+// empty when f fits
+func (f FleetDTO) Shortage(available FleetDTO) []string {
+	var missing []string
+	have := available.counts()
+	for i, need := range f.counts() {
+		if need.count > have[i].count {
+			missing = append(missing, fmt.Sprintf("%s %d/%d", need.name, need.count, have[i].count))
+		}
+	}
+	return missing
+}
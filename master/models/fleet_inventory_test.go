@@ -0,0 +1,25 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFleetShortage(t *testing.T) {
+	available := FleetDTO{LightFighter: 30, Cargo: 10}
+	tests := []struct {
+		name  string
+		fleet FleetDTO
+		want  []string
+	}{
+		{"fits", FleetDTO{LightFighter: 30, Cargo: 5}, nil},
+		{"empty", FleetDTO{}, nil},
+		{"short", FleetDTO{LightFighter: 50, Cargo: 10}, []string{"lf 50/30"}},
+		{"missing type", FleetDTO{Cargo: 11, Deathstar: 1}, []string{"ds 1/0", "cargo 11/10"}},
+	}
+	for _, tt := range tests {
+		if got := tt.fleet.Shortage(available); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Shortage() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "gorm.io/gorm"
+
+// FleetPreset is a named ship composition of a user that tasks reference
+// through Task.FleetPresetID instead of their own fleet. Editing it changes
+// the next run of every task using it.
+type FleetPreset struct {
+	gorm.Model
+	UserID    uint     `json:"user_id" gorm:"index"`
+	AccountID uint     `json:"account_id" gorm:"index"` // 0 lets every account of the user use it
+	Name      string   `json:"name"`
+	Ships     FleetDTO `json:"ships" gorm:"embedded"`
+}
+
+// UsableBy reports whether a task of accountID may reference the preset
+func (preset FleetPreset) UsableBy(accountID uint) bool {
+	return preset.AccountID == 0 || preset.AccountID == accountID
+}
@@ -0,0 +1,19 @@
+package models
+
+import "testing"
+
+func TestTaskShips(t *testing.T) {
+	task := Task{Fleet: Fleet{LightFighter: 5}}
+	if got := task.Ships(); got.LightFighter != 5 {
+		t.Errorf("Ships() without preset = %+v, want the task fleet", got)
+	}
+	preset := &FleetPreset{Ships: FleetDTO{Cargo: 7}}
+	task.FleetPresetID = 3
+	if got := task.Ships(); got.LightFighter != 5 {
+		t.Errorf("Ships() with an unloaded preset = %+v, want the task fleet", got)
+	}
+	task.FleetPreset = preset
+	if got := task.Ships(); got.Cargo != 7 || got.LightFighter != 0 {
+		t.Errorf("Ships() with preset = %+v, want the preset ships", got)
+	}
+}
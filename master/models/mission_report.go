@@ -0,0 +1,84 @@
+package models
+
+import "gorm.io/gorm"
+
+// Enum BattleOutcome, seen from the attacker
+const (
+	BATTLE_OUTCOME_WIN  = "win"
+	BATTLE_OUTCOME_LOSE = "lose"
+	BATTLE_OUTCOME_DRAW = "draw"
+)
+
+// Enum ExpeditionOutcome
+const (
+	EXPEDITION_OUTCOME_NOTHING     = "nothing"
+	EXPEDITION_OUTCOME_RESOURCES   = "resources"
+	EXPEDITION_OUTCOME_SHIPS       = "ships"
+	EXPEDITION_OUTCOME_DARK_MATTER = "dark_matter"
+	EXPEDITION_OUTCOME_PIRATES     = "pirates"
+	EXPEDITION_OUTCOME_ALIENS      = "aliens"
+	EXPEDITION_OUTCOME_DELAY       = "delay"
+	EXPEDITION_OUTCOME_EARLY       = "early"
+	EXPEDITION_OUTCOME_LOST        = "lost"
+)
+
+// ResourceAmounts are amounts of the three resources, as loot or debris
+type ResourceAmounts struct {
+	Metal     int64 `json:"metal"`
+	Crystal   int64 `json:"crystal"`
+	Deuterium int64 `json:"deuterium"`
+}
+
+// BattlePayload is the data of an attack result
+type BattlePayload struct {
+	Outcome        string           `json:"outcome"` // BATTLE_OUTCOME_*
+	Rounds         int              `json:"rounds"`
+	Loot           ResourceAmounts  `json:"loot"`
+	Debris         ResourceAmounts  `json:"debris"`
+	AttackerLosses map[string]int64 `json:"attacker_losses"` // ships lost by name
+	DefenderLosses map[string]int64 `json:"defender_losses"` // ships and defenses lost by name
+}
+
+// BattleReport is the outcome of an attack run, UUID is the one of its task log
+type BattleReport struct {
+	gorm.Model
+	UUID      string `json:"uuid" gorm:"unique"`
+	TaskID    uint   `json:"task_id" gorm:"index"`
+	AccountID uint   `json:"account_id" gorm:"index"`
+	TargetID  uint   `json:"target_id"`
+
+	Outcome        string           `json:"outcome"`
+	Rounds         int              `json:"rounds"`
+	Loot           ResourceAmounts  `json:"loot" gorm:"embedded;embeddedPrefix:loot_"`
+	Debris         ResourceAmounts  `json:"debris" gorm:"embedded;embeddedPrefix:debris_"`
+	AttackerLosses map[string]int64 `json:"attacker_losses" gorm:"serializer:json;type:text"`
+	DefenderLosses map[string]int64 `json:"defender_losses" gorm:"serializer:json;type:text"`
+	BackTimestamp  int64            `json:"back_timestamp"`
+}
+
+// ExpeditionPayload is the data of an explore result
+type ExpeditionPayload struct {
+	Outcome    string           `json:"outcome"` // EXPEDITION_OUTCOME_*
+	Resources  ResourceAmounts  `json:"resources"`
+	DarkMatter int64            `json:"dark_matter"`
+	ShipsFound map[string]int64 `json:"ships_found"`
+	ShipsLost  map[string]int64 `json:"ships_lost"`
+	Message    string           `json:"message"` // text of the game message
+}
+
+// ExpeditionReport is the findings of an explore run, UUID is the one of its task log
+type ExpeditionReport struct {
+	gorm.Model
+	UUID      string `json:"uuid" gorm:"unique"`
+	TaskID    uint   `json:"task_id" gorm:"index"`
+	AccountID uint   `json:"account_id" gorm:"index"`
+	TargetID  uint   `json:"target_id"`
+
+	Outcome       string           `json:"outcome"`
+	Resources     ResourceAmounts  `json:"resources" gorm:"embedded;embeddedPrefix:resource_"`
+	DarkMatter    int64            `json:"dark_matter"`
+	ShipsFound    map[string]int64 `json:"ships_found" gorm:"serializer:json;type:text"`
+	ShipsLost     map[string]int64 `json:"ships_lost" gorm:"serializer:json;type:text"`
+	Message       string           `json:"message" gorm:"type:text"`
+	BackTimestamp int64            `json:"back_timestamp"`
+}
@@ -13,9 +13,64 @@ func AutoMigrate(db *gorm.DB) {
 		&Task{},
 		&Target{},
 		&TaskLog{},
+		&TaskTransition{},
+		&DeadLetter{},
+		&TaskTemplate{},
+		&FleetPreset{},
+		&FleetInventory{},
+		&TransportRecord{},
+		&SpyReport{},
+		&BattleReport{},
+		&ExpeditionReport{},
+		&UniversePlanet{},
 	)
 	if err != nil {
 		log.Fatal("Error during migration: %v",
 			zap.Error(err))
 	}
+	if err := MigrateStartPlanets(db); err != nil {
+		log.Fatal("Error during start planet migration",
+			zap.Error(err))
+	}
+}
+
+// MigrateStartPlanets moves start planets saved before Target.StartTaskID
+// existed out of the targets of their task. Such a row has the task in
+// task_id, it is told apart from the targets by the position the fleet
+// inventory knows for the start planet. Tasks whose start planet was never
+// queried keep the row, the migration runs again on every start.
+func MigrateStartPlanets(db *gorm.DB) error {
+	var tasks []Task
+	if err := db.Select("id", "account_id", "start_planet_id").
+		Where("start_planet_id > 0").
+		Where("id NOT IN (?)", db.Model(&Target{}).Select("start_task_id").Where("start_task_id > 0")).
+		Find(&tasks).Error; err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		var planet FleetInventory
+		if err := db.Where("account_id = ? AND planet_id = ?", task.AccountID, task.StartPlanetID).
+			Limit(1).Find(&planet).Error; err != nil {
+			return err
+		}
+		position, err := ParsePosition(planet.Position)
+		if planet.ID == 0 || err != nil {
+			continue
+		}
+		var start Target
+		if err := db.Where(map[string]interface{}{"task_id": task.ID, "galaxy": position.Galaxy,
+			"system": position.System, "planet": position.Planet, "is_moon": position.Is_moon}).
+			Order("id").Limit(1).Find(&start).Error; err != nil {
+			return err
+		}
+		if start.ID == 0 {
+			continue
+		}
+		if err := db.Model(&start).Updates(map[string]interface{}{"task_id": 0, "start_task_id": task.ID}).Error; err != nil {
+			return err
+		}
+		log.Info("[Migration] start planet moved out of the task targets",
+			zap.Uint("task_id", task.ID), zap.Uint("target_id", start.ID))
+	}
+	return nil
 }
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMigrateStartPlanets(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+	if err := db.AutoMigrate(&Task{}, &Target{}, &FleetInventory{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	// Rows as the old schema saved them, the start planet among the targets
+	queried := &Task{AccountID: 5, StartPlanetID: 7, Targets: []Target{
+		{Galaxy: 1, System: 2, Planet: 3}, {Galaxy: 1, System: 2, Planet: 9}, {Galaxy: 1, System: 2, Planet: 3, Is_moon: true}}}
+	unknown := &Task{AccountID: 6, StartPlanetID: 8, Targets: []Target{{Galaxy: 4, System: 4, Planet: 4}}}
+	for _, task := range []*Task{queried, unknown} {
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+	if err := db.Create(&FleetInventory{AccountID: 5, PlanetID: 7, Position: "1:2:3:0"}).Error; err != nil {
+		t.Fatalf("create inventory: %v", err)
+	}
+
+	for i := 0; i < 2; i++ { // runs on every start
+		if err := MigrateStartPlanets(db); err != nil {
+			t.Fatalf("MigrateStartPlanets() error = %v", err)
+		}
+	}
+
+	var got Task
+	if err := db.Preload("StartPlanet").Preload("Targets").First(&got, queried.ID).Error; err != nil {
+		t.Fatalf("find task: %v", err)
+	}
+	if got.StartPlanet.ID != queried.Targets[0].ID || len(got.Targets) != 2 {
+		t.Errorf("start planet = %d with %d targets, want %d with 2", got.StartPlanet.ID, len(got.Targets), queried.Targets[0].ID)
+	}
+	var kept Task
+	if err := db.Preload("StartPlanet").Preload("Targets").First(&kept, unknown.ID).Error; err != nil {
+		t.Fatalf("find task: %v", err)
+	}
+	if kept.StartPlanet.ID != 0 || len(kept.Targets) != 1 {
+		t.Errorf("never queried task = start planet %d with %d targets, want none with 1", kept.StartPlanet.ID, len(kept.Targets))
+	}
+}
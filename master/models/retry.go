@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// RetryPolicy configures how failed runs of a task are retried.
+// Zero fields fall back to the defaults of the task type.
+type RetryPolicy struct {
+	MaxAttempts  int   `json:"max_attempts" yaml:"max_attempts"`   // tries on the same target before moving on, 0 or 1 moves on at once
+	BackoffBase  int64 `json:"backoff_base" yaml:"backoff_base"`   // seconds before the first retry, doubled on each further failure
+	BackoffMax   int64 `json:"backoff_max" yaml:"backoff_max"`     // cap of the backoff in seconds
+	DisableAfter int   `json:"disable_after" yaml:"disable_after"` // consecutive failures before the task is disabled, negative never disables
+}
+
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 0 || p.BackoffBase < 0 || p.BackoffMax < 0 {
+		return errors.New("retry settings must not be negative")
+	}
+	if p.BackoffMax > 0 && p.BackoffBase > p.BackoffMax {
+		return errors.New("backoff_base must not exceed backoff_max")
+	}
+	return nil
+}
+
+// WithDefaults fills the zero fields of p from defaults
+func (p RetryPolicy) WithDefaults(defaults RetryPolicy) RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = defaults.MaxAttempts
+	}
+	if p.BackoffBase == 0 {
+		p.BackoffBase = defaults.BackoffBase
+	}
+	if p.BackoffMax == 0 {
+		p.BackoffMax = defaults.BackoffMax
+	}
+	if p.DisableAfter == 0 {
+		p.DisableAfter = defaults.DisableAfter
+	}
+	return p
+}
+
+// Backoff returns the wait before the next try after failures consecutive failures
+func (p RetryPolicy) Backoff(failures int) time.Duration {
+	delay := time.Duration(p.BackoffBase) * time.Second
+	limit := time.Duration(p.BackoffMax) * time.Second
+	for i := 1; i < failures; i++ {
+		if limit > 0 && delay >= limit {
+			break
+		}
+		delay *= 2
+	}
+	if limit > 0 && delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// ShouldDisable reports whether failures consecutive failures disable the task
+func (p RetryPolicy) ShouldDisable(failures int) bool {
+	return p.DisableAfter > 0 && failures >= p.DisableAfter
+}
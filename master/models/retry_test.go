@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_WithDefaults_Validate(t *testing.T) {
+	defaults := RetryPolicy{BackoffBase: 300, BackoffMax: 3600, DisableAfter: 10}
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		wantErr bool
+	}{
+		{"Defaults only", RetryPolicy{}, false},
+		{"Base below the default cap", RetryPolicy{BackoffBase: 600}, false},
+		{"Base above the default cap", RetryPolicy{BackoffBase: 7200}, true},
+		{"Base above its own cap", RetryPolicy{BackoffBase: 7200, BackoffMax: 600}, true},
+		{"Own cap above the base", RetryPolicy{BackoffBase: 7200, BackoffMax: 14400}, false},
+		{"Negative attempts", RetryPolicy{MaxAttempts: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.WithDefaults(defaults).Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BackoffBase: 300, BackoffMax: 3600}
+	want := []time.Duration{5 * time.Minute, 10 * time.Minute, 20 * time.Minute, 40 * time.Minute, time.Hour, time.Hour}
+	for i, w := range want {
+		if got := policy.Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
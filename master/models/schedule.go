@@ -0,0 +1,261 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Enum ScheduleType
+const (
+	SCHEDULE_TYPE_INTERVAL = "interval" // every N minutes after the fleet returns
+	SCHEDULE_TYPE_CRON     = "cron"     // launch on a cron expression
+	SCHEDULE_TYPE_WINDOW   = "window"   // interval, but only inside a time-of-day window
+)
+
+// Schedule describes when a task is allowed to launch.
+// An empty schedule behaves like the old fixed delay after return.
+type Schedule struct {
+	Type            string `json:"type" yaml:"type"`
+	Cron            string `json:"cron" yaml:"cron"`                         // minute hour day-of-month month day-of-week
+	IntervalMinutes int    `json:"interval_minutes" yaml:"interval_minutes"` // 0 uses the default delay
+	WindowStart     string `json:"window_start" yaml:"window_start"`         // HH:MM
+	WindowEnd       string `json:"window_end" yaml:"window_end"`             // HH:MM, may be earlier than start to wrap midnight
+	Timezone        string `json:"timezone" yaml:"timezone"`                 // IANA name, empty uses server local time
+}
+
+func (s Schedule) Validate() error {
+	if s.IntervalMinutes < 0 {
+		return errors.New("interval_minutes must not be negative")
+	}
+	if _, err := s.location(); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	switch s.Type {
+	case "", SCHEDULE_TYPE_INTERVAL:
+		return nil
+	case SCHEDULE_TYPE_CRON:
+		_, err := parseCron(s.Cron)
+		return err
+	case SCHEDULE_TYPE_WINDOW:
+		_, _, err := s.window()
+		return err
+	default:
+		return fmt.Errorf("unknown schedule type %q", s.Type)
+	}
+}
+
+// Constrained reports whether the schedule restricts launch times,
+// as opposed to a plain delay after return.
+func (s Schedule) Constrained() bool {
+	return s.Type == SCHEDULE_TYPE_CRON || s.Type == SCHEDULE_TYPE_WINDOW
+}
+
+// Align returns the earliest allowed launch time at or after t.
+// Invalid schedules never block a task, they return t unchanged.
+func (s Schedule) Align(t time.Time) time.Time {
+	loc, err := s.location()
+	if err != nil {
+		return t
+	}
+	switch s.Type {
+	case SCHEDULE_TYPE_CRON:
+		spec, err := parseCron(s.Cron)
+		if err != nil {
+			return t
+		}
+		if next, ok := spec.next(t.In(loc)); ok {
+			return next
+		}
+		return t
+	case SCHEDULE_TYPE_WINDOW:
+		start, end, err := s.window()
+		if err != nil {
+			return t
+		}
+		return alignWindow(t.In(loc), start, end)
+	default:
+		return t
+	}
+}
+
+// NextAfterReturn computes the next launch once the fleet is back at back.
+func (s Schedule) NextAfterReturn(back time.Time, defaultDelay time.Duration) time.Time {
+	delay := defaultDelay
+	if s.Type != SCHEDULE_TYPE_CRON && s.IntervalMinutes > 0 {
+		delay = time.Duration(s.IntervalMinutes) * time.Minute
+	}
+	return s.Align(back.Add(delay))
+}
+
+func (s Schedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+func (s Schedule) window() (int, int, error) {
+	start, err := parseClock(s.WindowStart)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid window_start: %w", err)
+	}
+	end, err := parseClock(s.WindowEnd)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid window_end: %w", err)
+	}
+	return start, end, nil
+}
+
+// parseClock parses HH:MM into minutes of the day
+func parseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("%q is not HH:MM", value)
+	}
+	hour, err1 := strconv.Atoi(parts[0])
+	minute, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("%q is not HH:MM", value)
+	}
+	return hour*60 + minute, nil
+}
+
+func alignWindow(t time.Time, start, end int) time.Time {
+	if start == end { // whole day
+		return t
+	}
+	minute := t.Hour()*60 + t.Minute()
+	inside := minute >= start && minute < end
+	if start > end { // wraps midnight
+		inside = minute >= start || minute < end
+	}
+	if inside {
+		return t
+	}
+	opening := time.Date(t.Year(), t.Month(), t.Day(), start/60, start%60, 0, 0, t.Location())
+	if !opening.After(t) {
+		opening = opening.AddDate(0, 0, 1)
+	}
+	return opening
+}
+
+// cronSpec is a parsed 5-field cron expression
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+var cronRanges = [5][2]int{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 7},  // day of week, 0 and 7 are Sunday
+}
+
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+	var bits [5]uint64
+	for i, field := range fields {
+		b, err := parseCronField(field, cronRanges[i][0], cronRanges[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &cronSpec{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			rangePart = part[:idx]
+			step, err = strconv.Atoi(part[idx+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+		low, high := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", part)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value in %q", part)
+				}
+			} else if step > 1 {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// next returns the first matching minute at or after t
+func (c *cronSpec) next(t time.Time) (time.Time, bool) {
+	loc := t.Location()
+	cur := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+	if cur.Before(t) {
+		cur = cur.Add(time.Minute)
+	}
+	limit := cur.AddDate(5, 0, 0)
+	for cur.Before(limit) {
+		if c.month&(1<<uint(cur.Month())) == 0 {
+			cur = time.Date(cur.Year(), cur.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(cur) {
+			cur = time.Date(cur.Year(), cur.Month(), cur.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(cur.Hour())) == 0 {
+			cur = time.Date(cur.Year(), cur.Month(), cur.Day(), cur.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(cur.Minute())) == 0 {
+			cur = cur.Add(time.Minute)
+			continue
+		}
+		return cur, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Align(t *testing.T) {
+	loc := time.UTC
+	base := time.Date(2024, 5, 10, 14, 30, 20, 0, loc) // Friday
+	tests := []struct {
+		name     string
+		schedule Schedule
+		from     time.Time
+		want     time.Time
+	}{
+		{
+			name:     "Interval schedule is never moved",
+			schedule: Schedule{Type: SCHEDULE_TYPE_INTERVAL, IntervalMinutes: 10},
+			from:     base,
+			want:     base,
+		},
+		{
+			name:     "Cron every 15 minutes",
+			schedule: Schedule{Type: SCHEDULE_TYPE_CRON, Cron: "*/15 * * * *", Timezone: "UTC"},
+			from:     base,
+			want:     time.Date(2024, 5, 10, 14, 45, 0, 0, loc),
+		},
+		{
+			name:     "Cron at night on weekdays",
+			schedule: Schedule{Type: SCHEDULE_TYPE_CRON, Cron: "0 2 * * 1-5", Timezone: "UTC"},
+			from:     base,
+			want:     time.Date(2024, 5, 13, 2, 0, 0, 0, loc),
+		},
+		{
+			name:     "Window wrapping midnight, outside",
+			schedule: Schedule{Type: SCHEDULE_TYPE_WINDOW, WindowStart: "22:00", WindowEnd: "06:00", Timezone: "UTC"},
+			from:     base,
+			want:     time.Date(2024, 5, 10, 22, 0, 0, 0, loc),
+		},
+		{
+			name:     "Window wrapping midnight, inside",
+			schedule: Schedule{Type: SCHEDULE_TYPE_WINDOW, WindowStart: "22:00", WindowEnd: "06:00", Timezone: "UTC"},
+			from:     time.Date(2024, 5, 11, 3, 0, 0, 0, loc),
+			want:     time.Date(2024, 5, 11, 3, 0, 0, 0, loc),
+		},
+		{
+			name:     "Window already closed today",
+			schedule: Schedule{Type: SCHEDULE_TYPE_WINDOW, WindowStart: "08:00", WindowEnd: "12:00", Timezone: "UTC"},
+			from:     base,
+			want:     time.Date(2024, 5, 11, 8, 0, 0, 0, loc),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.schedule.Validate(); err != nil {
+				t.Fatalf("Schedule.Validate() error = %v", err)
+			}
+			if got := tt.schedule.Align(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Schedule.Align() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		wantErr  bool
+	}{
+		{"Empty schedule", Schedule{}, false},
+		{"Bad cron field count", Schedule{Type: SCHEDULE_TYPE_CRON, Cron: "* * *"}, true},
+		{"Cron out of range", Schedule{Type: SCHEDULE_TYPE_CRON, Cron: "60 * * * *"}, true},
+		{"Bad window", Schedule{Type: SCHEDULE_TYPE_WINDOW, WindowStart: "25:00", WindowEnd: "06:00"}, true},
+		{"Unknown type", Schedule{Type: "weekly"}, true},
+		{"Negative interval", Schedule{IntervalMinutes: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.schedule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Schedule.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import "gorm.io/gorm"
+
+// SpyResources are the resources seen on a spied planet
+type SpyResources struct {
+	Metal     int64 `json:"metal"`
+	Crystal   int64 `json:"crystal"`
+	Deuterium int64 `json:"deuterium"`
+	Energy    int64 `json:"energy"`
+}
+
+// SpyReport is the intelligence a spy run brought back about a planet.
+// Counts are keyed by the names the node reports, nil when the probes could
+// not see that part of the planet.
+type SpyReport struct {
+	gorm.Model
+	AccountID  uint             `json:"account_id" gorm:"index"`
+	TaskID     uint             `json:"task_id" gorm:"index"`
+	UUID       string           `json:"uuid" gorm:"unique"`
+	TargetID   uint             `json:"target_id"`
+	Galaxy     int              `json:"galaxy" gorm:"index:idx_spy_report_position"`
+	System     int              `json:"system" gorm:"index:idx_spy_report_position"`
+	Planet     int              `json:"planet" gorm:"index:idx_spy_report_position"`
+	IsMoon     bool             `json:"is_moon" gorm:"index:idx_spy_report_position"`
+	ReportedAt int64            `json:"reported_at" gorm:"index"` // Unix timestamp of the report
+	Resources  SpyResources     `json:"resources" gorm:"embedded;embeddedPrefix:resource_"`
+	Fleet      map[string]int64 `json:"fleet" gorm:"serializer:json;type:text"`
+	Defense    map[string]int64 `json:"defense" gorm:"serializer:json;type:text"`
+	Buildings  map[string]int64 `json:"buildings" gorm:"serializer:json;type:text"`
+}
+
+// SpyReportData is the msg of a successful spy result
+type SpyReportData struct {
+	ReportedAt int64            `json:"reported_at"`
+	Resources  SpyResources     `json:"resources"`
+	Fleet      map[string]int64 `json:"fleet"`
+	Defense    map[string]int64 `json:"defense"`
+	Buildings  map[string]int64 `json:"buildings"`
+}
+
+// SpyReportFilter selects spy reports, zero fields do not filter
+type SpyReportFilter struct {
+	Target   *Target // only reports about these coordinates
+	Since    int64   // Unix timestamp of the report, inclusive
+	Until    int64   // Unix timestamp of the report, exclusive
+	Page     int     // from 1
+	PageSize int
+}
@@ -1,10 +1,12 @@
 package models
 
+// Star is a position of the universe, StarID is the game id of the planet there.
+// The index tags make the position unique per server in UniversePlanet.
 type Star struct {
 	// gorm.Model // NOTE: Is this necessary?
-	Galaxy   int  `json:"galaxy"`
-	Solar    int  `json:"solar"`
-	Location int  `json:"location"`
+	Galaxy   int  `json:"galaxy" gorm:"uniqueIndex:idx_universe_position,priority:2"`
+	Solar    int  `json:"solar" gorm:"uniqueIndex:idx_universe_position,priority:3"`
+	Location int  `json:"location" gorm:"uniqueIndex:idx_universe_position,priority:4"`
 	StarID   int  `json:"star_id"` // For node use enemy will be empty
 	IsMoon   bool `json:"is_moon"`
 }
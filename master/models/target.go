@@ -13,6 +13,17 @@ type Target struct {
 	Planet  int  `json:"planet"`
 	Is_moon bool `json:"is_moon"`
 	TaskID  uint `json:"task_id"`
+
+	StartTaskID uint `json:"-" gorm:"index"` // task this is the start planet of, its TaskID stays 0
+
+	// Selection settings and per-target stats, see target_strategy.go
+	Weight              int   `json:"weight"` // weighted strategy, 0 counts as 1
+	HitCount            int   `json:"hit_count"`
+	FailureCount        int   `json:"failure_count"`
+	ConsecutiveFailures int   `json:"consecutive_failures"`
+	LastHitAt           int64 `json:"last_hit_at"`     // Unix timestamp of the last successful run
+	LastAttemptAt       int64 `json:"last_attempt_at"` // Unix timestamp of the last run sent to it, successful or not
+	Picked              bool  `json:"picked"`          // already used in the current random cycle
 }
 
 func (t Target) String() string {
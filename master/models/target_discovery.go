@@ -0,0 +1,184 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Game distances, see FlightDistance
+const (
+	DISTANCE_GALAXY     = 20000 // per galaxy between
+	DISTANCE_SYSTEM     = 2700  // base between systems of a galaxy
+	DISTANCE_PER_SYSTEM = 95
+	DISTANCE_PLANET     = 1000 // base between planets of a system
+	DISTANCE_PER_PLANET = 5
+	DISTANCE_SAME       = 5 // planet to its own moon
+)
+
+// MAX_PROPOSALS is the largest number of targets one proposal returns
+const MAX_PROPOSALS = 100
+
+// TargetCriteria filters and limits the targets proposed for a task
+type TargetCriteria struct {
+	Origin       *Target // nil to measure from the start planet of the task
+	MaxDistance  int     // game distance from the origin, see FlightDistance
+	InactiveOnly bool    // only inactive or long inactive players
+	MinLoot      int64   // loot of the last spy report, 0 also keeps planets never spied
+	HasMoon      *bool   // nil for planets with or without moon
+	Limit        int     // 0 means 20
+}
+
+func (c TargetCriteria) Validate() error {
+	if c.MaxDistance <= 0 {
+		return errors.New("max_distance must be positive")
+	}
+	if c.MinLoot < 0 {
+		return errors.New("min_loot must not be negative")
+	}
+	if c.Limit < 0 || c.Limit > MAX_PROPOSALS {
+		return fmt.Errorf("limit must not be negative nor above %d", MAX_PROPOSALS)
+	}
+	return nil
+}
+
+// TargetProposal is a planet of the universe catalog proposed as target
+type TargetProposal struct {
+	Galaxy       int    `json:"galaxy"`
+	System       int    `json:"system"`
+	Planet       int    `json:"planet"`
+	PlanetName   string `json:"planet_name"`
+	PlayerName   string `json:"player_name"`
+	Alliance     string `json:"alliance"`
+	Inactive     bool   `json:"inactive"`
+	LongInactive bool   `json:"long_inactive"`
+	HasMoon      bool   `json:"has_moon"`
+	Distance     int    `json:"distance"`
+	Loot         int64  `json:"loot"`          // 0 when never spied
+	SpyReportID  uint   `json:"spy_report_id"` // last spy report, 0 when none
+	ReportedAt   int64  `json:"reported_at"`
+}
+
+// ParsePosition reads a galaxy:system:planet:is_moon position, the form of Target.String
+func ParsePosition(position string) (Target, error) {
+	parts := strings.Split(position, ":")
+	if len(parts) != 4 {
+		return Target{}, fmt.Errorf("%q is not galaxy:system:planet:is_moon", position)
+	}
+	var values [4]int
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return Target{}, fmt.Errorf("%q is not galaxy:system:planet:is_moon", position)
+		}
+		values[i] = value
+	}
+	return Target{Galaxy: values[0], System: values[1], Planet: values[2], Is_moon: values[3] == 1}, nil
+}
+
+// FlightDistance is the distance the game uses for flight time and fuel
+// between two positions. Galaxies do not wrap around.
+func FlightDistance(from, to Target) int {
+	switch {
+	case from.Galaxy != to.Galaxy:
+		return DISTANCE_GALAXY * abs(from.Galaxy-to.Galaxy)
+	case from.System != to.System:
+		return DISTANCE_SYSTEM + DISTANCE_PER_SYSTEM*abs(from.System-to.System)
+	case from.Planet != to.Planet:
+		return DISTANCE_PLANET + DISTANCE_PER_PLANET*abs(from.Planet-to.Planet)
+	default:
+		return DISTANCE_SAME
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Loot is what an attack may carry away, half of the resources of the planet
+func (r SpyResources) Loot() int64 {
+	return (r.Metal + r.Crystal + r.Deuterium) / 2
+}
+
+// PositionKey identifies a planet position, moons share the one of their planet
+func PositionKey(galaxy, system, planet int) string {
+	return fmt.Sprintf("%d:%d:%d", galaxy, system, planet)
+}
+
+// ProposeTargets filters the planets of a catalog by the criteria and ranks
+// them, most loot first then closest. reports holds the last spy report of
+// every spied planet and exclude the positions never to propose, both by
+// "galaxy:system:planet".
+func ProposeTargets(origin Target, planets []UniversePlanet, reports map[string]SpyReport,
+	exclude map[string]bool, criteria TargetCriteria) []TargetProposal {
+	proposals := []TargetProposal{}
+	for _, planet := range planets {
+		star := planet.Star
+		key := PositionKey(star.Galaxy, star.Solar, star.Location)
+		activity := planet.Activity
+		if exclude[key] || activity.Vacation || activity.Banned || activity.Noob || activity.Strong {
+			continue // not attackable
+		}
+		if criteria.InactiveOnly && !activity.Inactive && !activity.LongInactive {
+			continue
+		}
+		if criteria.HasMoon != nil && planet.HasMoon != *criteria.HasMoon {
+			continue
+		}
+		position := Target{Galaxy: star.Galaxy, System: star.Solar, Planet: star.Location}
+		distance := FlightDistance(origin, position)
+		if distance > criteria.MaxDistance {
+			continue
+		}
+		proposal := TargetProposal{
+			Galaxy:       star.Galaxy,
+			System:       star.Solar,
+			Planet:       star.Location,
+			PlanetName:   planet.PlanetName,
+			PlayerName:   planet.PlayerName,
+			Alliance:     planet.Alliance,
+			Inactive:     activity.Inactive,
+			LongInactive: activity.LongInactive,
+			HasMoon:      planet.HasMoon,
+			Distance:     distance,
+		}
+		if report, ok := reports[key]; ok {
+			proposal.Loot = report.Resources.Loot()
+			proposal.SpyReportID = report.ID
+			proposal.ReportedAt = report.ReportedAt
+		}
+		if proposal.Loot < criteria.MinLoot {
+			continue
+		}
+		proposals = append(proposals, proposal)
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		a, b := proposals[i], proposals[j]
+		if a.Loot != b.Loot {
+			return a.Loot > b.Loot
+		}
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if a.Galaxy != b.Galaxy {
+			return a.Galaxy < b.Galaxy
+		}
+		if a.System != b.System {
+			return a.System < b.System
+		}
+		return a.Planet < b.Planet
+	})
+	limit := criteria.Limit
+	if limit == 0 {
+		limit = 20
+	}
+	if len(proposals) > limit {
+		proposals = proposals[:limit]
+	}
+	return proposals
+}
@@ -0,0 +1,64 @@
+package models
+
+import "testing"
+
+func TestFlightDistance(t *testing.T) {
+	from := Target{Galaxy: 1, System: 100, Planet: 8}
+	tests := []struct {
+		to   Target
+		want int
+	}{
+		{Target{Galaxy: 3, System: 1, Planet: 1}, 40000},
+		{Target{Galaxy: 1, System: 110, Planet: 8}, 2700 + 950},
+		{Target{Galaxy: 1, System: 100, Planet: 4}, 1000 + 20},
+		{Target{Galaxy: 1, System: 100, Planet: 8, Is_moon: true}, 5},
+	}
+	for _, tt := range tests {
+		if got := FlightDistance(from, tt.to); got != tt.want {
+			t.Errorf("FlightDistance(%s, %s) = %d, want %d", from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestProposeTargets(t *testing.T) {
+	planet := func(system, location int, activity PlanetActivity, hasMoon bool) UniversePlanet {
+		return UniversePlanet{Star: Star{Galaxy: 1, Solar: system, Location: location}, Activity: activity, HasMoon: hasMoon}
+	}
+	planets := []UniversePlanet{
+		planet(100, 1, PlanetActivity{Inactive: true}, false),
+		planet(102, 5, PlanetActivity{Inactive: true}, true),
+		planet(101, 3, PlanetActivity{}, false),
+		planet(100, 9, PlanetActivity{Vacation: true}, false),
+		planet(100, 10, PlanetActivity{LongInactive: true}, false),
+		planet(150, 1, PlanetActivity{Inactive: true}, false), // too far
+	}
+	reports := map[string]SpyReport{
+		PositionKey(1, 102, 5): {Resources: SpyResources{Metal: 3000, Crystal: 1000}},
+	}
+	exclude := map[string]bool{PositionKey(1, 100, 10): true}
+	origin := Target{Galaxy: 1, System: 100, Planet: 8}
+
+	got := ProposeTargets(origin, planets, reports, exclude, TargetCriteria{MaxDistance: 3000})
+	want := []string{"1:102:5", "1:100:1", "1:101:3"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d proposals, want %d: %+v", len(got), len(want), got)
+	}
+	for i, proposal := range got {
+		if key := PositionKey(proposal.Galaxy, proposal.System, proposal.Planet); key != want[i] {
+			t.Errorf("proposal %d = %s, want %s", i, key, want[i])
+		}
+	}
+	if got[0].Loot != 2000 {
+		t.Errorf("loot = %d, want 2000", got[0].Loot)
+	}
+
+	withoutMoon := false
+	got = ProposeTargets(origin, planets, reports, exclude, TargetCriteria{MaxDistance: 3000, InactiveOnly: true, HasMoon: &withoutMoon})
+	if len(got) != 1 || got[0].Planet != 1 {
+		t.Errorf("inactive without moon = %+v, want only 1:100:1", got)
+	}
+	got = ProposeTargets(origin, planets, reports, exclude, TargetCriteria{MaxDistance: 3000, MinLoot: 1})
+	if len(got) != 1 || got[0].System != 102 {
+		t.Errorf("min loot = %+v, want only 1:102:5", got)
+	}
+}
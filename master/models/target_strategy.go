@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// Enum TargetStrategy
+const (
+	TARGET_STRATEGY_ROUND_ROBIN  = "round_robin"  // Targets[NextIndex], then the next one
+	TARGET_STRATEGY_WEIGHTED     = "weighted"     // random, proportional to Target.Weight
+	TARGET_STRATEGY_RANDOM       = "random"       // random without repeats until every target was used
+	TARGET_STRATEGY_LEAST_RECENT = "least_recent" // the target with the oldest LastAttemptAt
+	TARGET_STRATEGY_SKIP_FAILED  = "skip_failed"  // round robin over targets that did not fail SkipFailedAfter times in a row
+)
+
+const defaultSkipFailedAfter = 3
+
+func ValidateTargetStrategy(strategy string) error {
+	switch strategy {
+	case "", TARGET_STRATEGY_ROUND_ROBIN, TARGET_STRATEGY_WEIGHTED, TARGET_STRATEGY_RANDOM,
+		TARGET_STRATEGY_LEAST_RECENT, TARGET_STRATEGY_SKIP_FAILED:
+		return nil
+	default:
+		return fmt.Errorf("unknown target strategy %q", strategy)
+	}
+}
+
+// SelectTarget picks the index of the next target according to the task strategy.
+// It updates NextIndex and the Picked flags of the targets, the caller persists them.
+func (t *Task) SelectTarget() (int, error) {
+	if len(t.Targets) == 0 {
+		return 0, errors.New("no targets available for task")
+	}
+	// A failed target is retried first, see RetryPolicy.MaxAttempts
+	if t.RetryTargetID != 0 {
+		for i, target := range t.Targets {
+			if target.ID == t.RetryTargetID {
+				return i, nil
+			}
+		}
+	}
+	switch t.TargetStrategy {
+	case TARGET_STRATEGY_WEIGHTED:
+		return t.selectWeighted(), nil
+	case TARGET_STRATEGY_RANDOM:
+		return t.selectRandom(), nil
+	case TARGET_STRATEGY_LEAST_RECENT:
+		return t.selectLeastRecent(), nil
+	case TARGET_STRATEGY_SKIP_FAILED:
+		return t.selectSkipFailed()
+	default:
+		return t.selectRoundRobin()
+	}
+}
+
+func (t *Task) selectRoundRobin() (int, error) {
+	if t.NextIndex >= len(t.Targets) || t.NextIndex < 0 {
+		return 0, errors.New("invalid next_index")
+	}
+	current := t.NextIndex
+	t.NextIndex = (t.NextIndex + 1) % len(t.Targets)
+	return current, nil
+}
+
+func (t *Task) selectWeighted() int {
+	total := 0
+	for _, target := range t.Targets {
+		total += target.weight()
+	}
+	n := rand.Intn(total)
+	for i, target := range t.Targets {
+		if n < target.weight() {
+			return i
+		}
+		n -= target.weight()
+	}
+	return len(t.Targets) - 1
+}
+
+func (t *Task) selectRandom() int {
+	var candidates []int
+	for i, target := range t.Targets {
+		if !target.Picked {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 { // every target was used, start a new cycle
+		for i := range t.Targets {
+			t.Targets[i].Picked = false
+			candidates = append(candidates, i)
+		}
+	}
+	index := candidates[rand.Intn(len(candidates))]
+	t.Targets[index].Picked = true
+	return index
+}
+
+func (t *Task) selectLeastRecent() int {
+	best := 0
+	for i, target := range t.Targets {
+		if target.LastAttemptAt < t.Targets[best].LastAttemptAt {
+			best = i
+		}
+	}
+	return best
+}
+
+func (t *Task) selectSkipFailed() (int, error) {
+	threshold := t.SkipFailedAfter
+	if threshold <= 0 {
+		threshold = defaultSkipFailedAfter
+	}
+	start := t.NextIndex
+	if start >= len(t.Targets) || start < 0 {
+		start = 0
+	}
+	for i := 0; i < len(t.Targets); i++ {
+		index := (start + i) % len(t.Targets)
+		if t.Targets[index].ConsecutiveFailures < threshold {
+			t.NextIndex = (index + 1) % len(t.Targets)
+			return index, nil
+		}
+	}
+	return 0, fmt.Errorf("every target failed %d times in a row", threshold)
+}
+
+func (target Target) weight() int {
+	if target.Weight <= 0 {
+		return 1
+	}
+	return target.Weight
+}
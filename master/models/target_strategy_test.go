@@ -0,0 +1,67 @@
+package models
+
+import "testing"
+
+func newStrategyTask(strategy string, n int) *Task {
+	task := &Task{TargetStrategy: strategy}
+	for i := 0; i < n; i++ {
+		task.Targets = append(task.Targets, Target{Galaxy: 1, System: 1, Planet: i + 1})
+	}
+	return task
+}
+
+func TestTask_SelectTarget_RandomWithoutRepeats(t *testing.T) {
+	task := newStrategyTask(TARGET_STRATEGY_RANDOM, 5)
+	for cycle := 0; cycle < 3; cycle++ {
+		seen := map[int]bool{}
+		for i := 0; i < len(task.Targets); i++ {
+			index, err := task.SelectTarget()
+			if err != nil {
+				t.Fatalf("SelectTarget() error = %v", err)
+			}
+			if seen[index] {
+				t.Fatalf("cycle %d: target %d picked twice", cycle, index)
+			}
+			seen[index] = true
+		}
+	}
+}
+
+func TestTask_SelectTarget_SkipFailed(t *testing.T) {
+	task := newStrategyTask(TARGET_STRATEGY_SKIP_FAILED, 3)
+	task.SkipFailedAfter = 2
+	task.Targets[0].ConsecutiveFailures = 2
+	task.Targets[2].ConsecutiveFailures = 5
+
+	for i := 0; i < 3; i++ {
+		index, err := task.SelectTarget()
+		if err != nil {
+			t.Fatalf("SelectTarget() error = %v", err)
+		}
+		if index != 1 {
+			t.Errorf("SelectTarget() = %d, want 1", index)
+		}
+	}
+
+	task.Targets[1].ConsecutiveFailures = 2
+	if _, err := task.SelectTarget(); err == nil {
+		t.Errorf("SelectTarget() expected an error when every target failed")
+	}
+}
+
+func TestTask_SelectTarget_LeastRecent(t *testing.T) {
+	task := newStrategyTask(TARGET_STRATEGY_LEAST_RECENT, 3)
+	task.Targets[0].LastAttemptAt = 300
+	task.Targets[1].LastAttemptAt = 100
+	task.Targets[2].LastAttemptAt = 200
+	if index, _ := task.SelectTarget(); index != 1 {
+		t.Errorf("SelectTarget() = %d, want 1", index)
+	}
+
+	// A target that keeps failing has an old LastHitAt but a recent attempt
+	task.Targets[1].LastHitAt = 0
+	task.Targets[1].LastAttemptAt = 400
+	if index, _ := task.SelectTarget(); index != 2 {
+		t.Errorf("SelectTarget() after a failed attempt = %d, want 2", index)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"GalaxyEmpireWeb/logger"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,69 +15,140 @@ import (
 // Enum TaskType
 const (
 	TASKTYPE_ATTACK          = 1
+	TASKTYPE_TRANSPORT       = 3
 	TASKTYPE_EXPLORE         = 4
+	TASKTYPE_SPY             = 6
 	TASKTYPE_LOGIN           = 99
 	TASKTYPE_QUERY_PLANET_ID = 100
+	TASKTYPE_QUERY_FLEET     = 101
+	TASKTYPE_SCAN_GALAXY     = 102
 	MISSIONTYPE_ATTACK       = 1
+	MISSIONTYPE_TRANSPORT    = 3
+	MISSIONTYPE_SPY          = 6
 	MISSIONTYPE_EXPLORE      = 15
 )
 
+// Enum RunLimitType
 const (
-	TASK_STATUS_RUNNING = iota
-	TASK_STATUS_WAITING
-	TASK_STATUS_READY
+	RUN_LIMIT_RUNS   = "runs"   // RunLimit counts single runs
+	RUN_LIMIT_CYCLES = "cycles" // RunLimit counts full cycles over the target list
 )
 
 var log = logger.GetLogger()
 
-var TaskStatusMap = map[int]string{
-	TASK_STATUS_RUNNING: "running",
-	TASK_STATUS_WAITING: "waiting",
-	TASK_STATUS_READY:   "ready",
-} // TODO: need to rethink the status
-
 const (
 	TASK_RESULT_RUNNING = 0 // TODO: use var to def type
 	TASK_RESULT_SUCCESS = 1
 	TASK_RESULT_FAILED  = 2
+	TASK_RESULT_TIMEOUT = 3 // no result before the deadline of the log
+	TASK_RESULT_REPORT  = 4 // report of a run read after its result, never the status of a log
 )
 
 type Task struct {
 	gorm.Model
-	Name          string   `json:"name"`
-	NextStart     int64    `json:"next_start"` // Unix timestamp seconds
-	Enabled       bool     `json:"enabled"`
-	AccountID     uint     `json:"account_id"`
-	TaskType      int      `json:"task_type"`
-	Status        string   `json:"status"`
-	StartPlanet   Target   `json:"start_planet" gorm:"foreignKey:TaskID"`
-	StartPlanetID uint     `json:"start_planet_id"`
-	Targets       []Target `json:"targets" gorm:"foreignKey:TaskID"`
-	Repeat        int      `json:"repeat"`
-	NextIndex     int      `json:"next_index"`
-	TargetNum     int      `json:"target_num"`
-	Fleet         Fleet    `json:"fleet" gorm:"foreignKey:TaskID"`
+	Name          string        `json:"name"`
+	NextStart     int64         `json:"next_start"` // Unix timestamp seconds
+	Enabled       bool          `json:"enabled"`
+	AccountID     uint          `json:"account_id"`
+	TaskType      int           `json:"task_type"`
+	Status        string        `json:"status"` // see task_state.go, changed through transitions only
+	StartPlanet   Target        `json:"start_planet" gorm:"foreignKey:StartTaskID"`
+	StartPlanetID uint          `json:"start_planet_id"`
+	Targets       []Target      `json:"targets" gorm:"foreignKey:TaskID"`
+	Repeat        int           `json:"repeat"` // fleets sent by the node per run
+	NextIndex     int           `json:"next_index"`
+	TargetNum     int           `json:"target_num"`
+	Fleet         Fleet         `json:"fleet" gorm:"foreignKey:TaskID"`
+	FleetPresetID uint          `json:"fleet_preset_id" gorm:"index"`                  // 0 sends Fleet, otherwise the preset ships
+	FleetPreset   *FleetPreset  `json:"-" gorm:"foreignKey:FleetPresetID;-:migration"` // loaded for dispatch, never saved through the task
+	Cargo         CargoManifest `json:"cargo" gorm:"embedded;embeddedPrefix:cargo_"`   // resources carried by transport tasks
+	Schedule      Schedule      `json:"schedule" gorm:"embedded;embeddedPrefix:schedule_"`
+	RunLimit      int           `json:"run_limit"`      // 0 runs forever
+	RunLimitType  string        `json:"run_limit_type"` // runs or cycles, empty means runs
+	RunCount      int           `json:"run_count"`      // successful runs so far
+
+	TargetStrategy  string `json:"target_strategy"`   // see target_strategy.go, empty means round robin
+	SkipFailedAfter int    `json:"skip_failed_after"` // skip_failed threshold, 0 means 3
+
+	Retry               RetryPolicy `json:"retry" gorm:"embedded;embeddedPrefix:retry_"`
+	ConsecutiveFailures int         `json:"consecutive_failures"`
+	RetryTargetID       uint        `json:"retry_target_id"` // target retried by max_attempts, 0 when none
+	DisabledReason      string      `json:"disabled_reason"` // why the task was disabled automatically
+
+	TemplateID      uint `json:"template_id" gorm:"index"` // 0 when not built from a template
+	TemplateVersion int  `json:"template_version"`         // template version last applied
 }
 
 func (t Task) ToDTO() *TaskDTO {
 	return &TaskDTO{
-		Model:     t.Model,
-		Name:      t.Name,
-		NextStart: time.Unix(t.NextStart, 0),
-		Enabled:   t.Enabled,
-		AccountID: t.AccountID,
-		TaskType:  t.TaskType,
-		Targets:   t.Targets,
-		Repeat:    t.Repeat,
-		TargetNum: len(t.Targets),
-		Fleet:     t.Fleet,
+		Model:         t.Model,
+		Name:          t.Name,
+		NextStart:     time.Unix(t.NextStart, 0),
+		Enabled:       t.Enabled,
+		AccountID:     t.AccountID,
+		TaskType:      t.TaskType,
+		Status:        t.Status,
+		Targets:       t.Targets,
+		Repeat:        t.Repeat,
+		TargetNum:     len(t.Targets),
+		Fleet:         t.Fleet,
+		FleetPresetID: t.FleetPresetID,
+		Cargo:         t.Cargo,
+		Schedule:      t.Schedule,
+		RunLimit:      t.RunLimit,
+		RunLimitType:  t.RunLimitType,
+		RunCount:      t.RunCount,
+
+		TargetStrategy:  t.TargetStrategy,
+		SkipFailedAfter: t.SkipFailedAfter,
+
+		Retry:               t.Retry,
+		ConsecutiveFailures: t.ConsecutiveFailures,
+		DisabledReason:      t.DisabledReason,
+
+		TemplateID:      t.TemplateID,
+		TemplateVersion: t.TemplateVersion,
+	}
+}
+
+// Ships returns the fleet a run sends, the preset one when the task uses a loaded preset
+func (t Task) Ships() *FleetDTO {
+	if t.FleetPresetID != 0 && t.FleetPreset != nil {
+		ships := t.FleetPreset.Ships
+		return &ships
 	}
+	return t.Fleet.ToDTO()
 }
 
 func (t Task) GetEntityPrefix() string {
 	return "task_"
 }
 
+func (t Task) ValidateRunLimit() error {
+	if t.RunLimit < 0 {
+		return errors.New("run_limit must not be negative")
+	}
+	switch t.RunLimitType {
+	case "", RUN_LIMIT_RUNS, RUN_LIMIT_CYCLES:
+		return nil
+	default:
+		return fmt.Errorf("unknown run_limit_type %q", t.RunLimitType)
+	}
+}
+
+// RunLimitReached reports whether runCount runs use up the run limit of a task
+// with targetNum targets.
+func (t Task) RunLimitReached(runCount int, targetNum int) bool {
+	if t.RunLimit <= 0 {
+		return false
+	}
+	limit := t.RunLimit
+	if t.RunLimitType == RUN_LIMIT_CYCLES {
+		limit = t.RunLimit * targetNum
+	}
+	return runCount >= limit
+}
+
 func (t *Task) ToSingleTaskRequest(account *Account) (*SingleTaskRequest, error) {
 	// 基础验证
 	if len(t.Targets) == 0 {
@@ -86,15 +158,6 @@ func (t *Task) ToSingleTaskRequest(account *Account) (*SingleTaskRequest, error)
 		return nil, errors.New("no targets available for task")
 	}
 
-	// Validate NextIndex
-	if t.NextIndex >= len(t.Targets) {
-		log.Error("Task::ToSingleTaskRequest: invalid next_index",
-			zap.Uint("task_id", t.ID),
-			zap.Int("next_index", t.NextIndex),
-			zap.Int("targets_length", len(t.Targets)))
-		return nil, errors.New("invalid next_index")
-	}
-
 	// 验证账号信息
 	if account == nil {
 		log.Error("Task::ToSingleTaskRequest: account is nil",
@@ -102,18 +165,27 @@ func (t *Task) ToSingleTaskRequest(account *Account) (*SingleTaskRequest, error)
 		return nil, errors.New("account information missing")
 	}
 
-	// 验证并更新 NextIndex
-	currentIndex := t.NextIndex
-	t.NextIndex = (t.NextIndex + 1) % len(t.Targets)
+	// 按策略选择目标并更新 NextIndex
+	currentIndex, err := t.SelectTarget()
+	if err != nil {
+		log.Error("Task::ToSingleTaskRequest: failed to select target",
+			zap.Uint("task_id", t.ID),
+			zap.String("target_strategy", t.TargetStrategy),
+			zap.Int("next_index", t.NextIndex),
+			zap.Int("targets_length", len(t.Targets)),
+			zap.Error(err))
+		return nil, err
+	}
 
 	log.Debug("Task::ToSingleTaskRequest: preparing task",
 		zap.Uint("task_id", t.ID),
 		zap.String("task_name", t.Name),
+		zap.String("target_strategy", t.TargetStrategy),
 		zap.Int("current_index", currentIndex),
 		zap.Int("next_index", t.NextIndex),
 		zap.Int("targets_count", len(t.Targets)))
 
-	return &SingleTaskRequest{
+	request := &SingleTaskRequest{
 		TaskID:        t.ID,
 		UUID:          uuid.NewString(),
 		Name:          t.Name,
@@ -125,52 +197,78 @@ func (t *Task) ToSingleTaskRequest(account *Account) (*SingleTaskRequest, error)
 		StartPlanetID: t.StartPlanetID,
 		Target:        t.Targets[currentIndex], // 使用当前索引
 		Repeat:        t.Repeat,
-		Fleet:         t.Fleet.ToDTO(),
-	}, nil
+		Fleet:         t.Ships(),
+	}
+	if t.TaskType == TASKTYPE_TRANSPORT {
+		cargo := t.Cargo
+		request.Cargo = &cargo
+	}
+	return request, nil
 }
 
 type TaskDTO struct { // TODO: finish func
 	gorm.Model
-	Name      string    `json:"name"`
-	NextStart time.Time `json:"next_start"`
-	Enabled   bool      `json:"enabled"`
-	AccountID uint      `json:"account_id"`
-	TaskType  int       `json:"task_type"`
-	Targets   []Target  `json:"targets" gorm:"foreignKey:TaskID"`
-	Repeat    int       `json:"repeat"`
-	NextIndex int       `json:"next_index"`
-	TargetNum int       `json:"target_num"`
-	Fleet     Fleet     `json:"fleet" gorm:"foreignKey:TaskID"`
+	Name          string        `json:"name"`
+	NextStart     time.Time     `json:"next_start"`
+	Enabled       bool          `json:"enabled"`
+	AccountID     uint          `json:"account_id"`
+	TaskType      int           `json:"task_type"`
+	Status        string        `json:"status"`
+	Targets       []Target      `json:"targets" gorm:"foreignKey:TaskID"`
+	Repeat        int           `json:"repeat"`
+	NextIndex     int           `json:"next_index"`
+	TargetNum     int           `json:"target_num"`
+	Fleet         Fleet         `json:"fleet" gorm:"foreignKey:TaskID"`
+	FleetPresetID uint          `json:"fleet_preset_id"`
+	Cargo         CargoManifest `json:"cargo"`
+	Schedule      Schedule      `json:"schedule"`
+	RunLimit      int           `json:"run_limit"`
+	RunLimitType  string        `json:"run_limit_type"`
+	RunCount      int           `json:"run_count"`
+
+	TargetStrategy  string `json:"target_strategy"`
+	SkipFailedAfter int    `json:"skip_failed_after"`
+
+	Retry               RetryPolicy `json:"retry"`
+	ConsecutiveFailures int         `json:"consecutive_failures"`
+	DisabledReason      string      `json:"disabled_reason"`
+
+	TemplateID      uint `json:"template_id"`
+	TemplateVersion int  `json:"template_version"`
 }
 
 type SingleTaskRequest struct {
-	TaskID        uint        `json:"task_id"`
-	UUID          string      `json:"uuid"`
-	Name          string      `json:"name"`
-	NextStart     int64       `json:"next_start"` // Unix timestamp seconds
-	Enabled       bool        `json:"enabled"`
-	Account       AccountInfo `json:"account"`
-	TaskType      int         `json:"task_type"`
-	StartPlanet   Target      `json:"start_planet"`
-	StartPlanetID uint        `json:"start_planet_id"`
-	Target        Target      `json:"target"`
-	Repeat        int         `json:"repeat"`
-	Fleet         *FleetDTO   `json:"fleet"`
+	TaskID        uint           `json:"task_id"`
+	UUID          string         `json:"uuid"`
+	Name          string         `json:"name"`
+	NextStart     int64          `json:"next_start"` // Unix timestamp seconds
+	Enabled       bool           `json:"enabled"`
+	Account       AccountInfo    `json:"account"`
+	TaskType      int            `json:"task_type"`
+	StartPlanet   Target         `json:"start_planet"`
+	StartPlanetID uint           `json:"start_planet_id"`
+	Target        Target         `json:"target"`
+	Repeat        int            `json:"repeat"`
+	Fleet         *FleetDTO      `json:"fleet"`
+	Cargo         *CargoManifest `json:"cargo,omitempty"` // transport tasks only
+	Scan          *ScanRange     `json:"scan,omitempty"`  // galaxy scans only
 }
 type SingleTaskResponse struct {
-	TaskID        uint   `json:"task_id"`
-	UUID          string `json:"uuid"`
-	Status        int    `json:"status"` // 0 success, -1 failed
-	TaskType      int    `json:"task_type"`
-	BackTimestamp int64  `json:"back_ts"`
-	Msg           string `json:"msg"`
-	ErrMsg        string `json:"err_msg"`
+	TaskID        uint            `json:"task_id"`
+	UUID          string          `json:"uuid"`
+	Status        int             `json:"status"` // TASK_RESULT_*, running when a node takes the run
+	TaskType      int             `json:"task_type"`
+	BackTimestamp int64           `json:"back_ts"`
+	Msg           string          `json:"msg"`
+	ErrMsg        string          `json:"err_msg"`
+	Data          json.RawMessage `json:"data,omitempty"` // typed payload of the task type, see Payload
 }
 
-type TaskResponse struct {
-	TaskType string          `json:"task_type"`
-	Success  bool            `json:"success"`
-	Message  string          `json:"message"`
-	TaskID   int             `json:"task_id"`
-	Data     json.RawMessage `json:"data"` // 用于存储特定任务类型的数据
+// Payload returns the typed payload of a result. Nodes sending no data put
+// their JSON in Msg, it is used instead.
+func (r SingleTaskResponse) Payload() []byte {
+	if len(r.Data) > 0 && string(r.Data) != "null" {
+		return r.Data
+	}
+	return []byte(r.Msg)
 }
@@ -4,10 +4,29 @@ import "gorm.io/gorm"
 
 type TaskLog struct {
 	gorm.Model
-	TaskID   uint   `json:"task_id"`
-	TaskType int    `json:"task_type"`
-	UUID     string `json:"uuid" gorm:"unique"` // Unique limit
-	Status   int    `json:"status"`
-	Msg      string `json:"msg"`
-	ErrMsg   string `json:"err_msg"`
+	TaskID    uint   `json:"task_id" gorm:"index"`
+	AccountID uint   `json:"account_id" gorm:"index"` // 0 for logs written before accounts were recorded
+	TaskType  int    `json:"task_type"`
+	UUID      string `json:"uuid" gorm:"unique"` // Unique limit
+	Status    int    `json:"status"`
+	Msg       string `json:"msg"`
+	ErrMsg    string `json:"err_msg"`
+	TargetID  uint   `json:"target_id"`             // target this run was sent to, 0 for instant tasks
+	Deadline  int64  `json:"deadline" gorm:"index"` // Unix timestamp after which the run times out, 0 for old logs
+
+	DispatchedAt  int64 `json:"dispatched_at"`  // Unix timestamp the run was due to start, 0 for old logs
+	BackTimestamp int64 `json:"back_timestamp"` // Unix timestamp the fleet came back, reported by the node
+
+	DuplicateCount int `json:"duplicate_count"` // results ignored because the run had already finished
+}
+
+// TaskLogFilter selects task logs, zero fields do not filter
+type TaskLogFilter struct {
+	Status   *int  // TASK_RESULT_*
+	TaskType int   // TASKTYPE_*
+	Since    int64 // Unix timestamp, inclusive
+	Until    int64 // Unix timestamp, exclusive
+	Asc      bool  // oldest first instead of newest first
+	Page     int   // from 1
+	PageSize int
 }
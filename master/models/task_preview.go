@@ -0,0 +1,13 @@
+package models
+
+// TaskPreview is what the task generator would do with a task on its next pass
+type TaskPreview struct {
+	TaskID        uint    `json:"task_id"`
+	TaskName      string  `json:"task_name"`
+	AccountID     uint    `json:"account_id"`
+	WouldDispatch bool    `json:"would_dispatch"`
+	SkipReason    string  `json:"skip_reason,omitempty"` // same reasons as the generator logs
+	NextStart     int64   `json:"next_start"`            // after the schedule alignment
+	DelaySeconds  int64   `json:"delay_seconds"`         // delay of the queued message
+	Target        *Target `json:"target,omitempty"`
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const TASK_DOCUMENT_VERSION = 1
+
+// Enum TaskDocumentFormat
+const (
+	TASK_DOCUMENT_YAML = "yaml"
+	TASK_DOCUMENT_JSON = "json"
+)
+
+// TaskDocument is the portable form of the tasks of an account, used by import and export
+type TaskDocument struct {
+	Version int        `json:"version" yaml:"version"`
+	Tasks   []TaskSpec `json:"tasks" yaml:"tasks"`
+}
+
+// TaskSpec is the configuration of a task without ids and runtime state.
+// Tasks are matched by name on import.
+type TaskSpec struct {
+	Name            string        `json:"name" yaml:"name"`
+	TaskType        int           `json:"task_type" yaml:"task_type"`
+	StartPlanetID   uint          `json:"start_planet_id" yaml:"start_planet_id"` // game planet id, required to create a task
+	Enabled         bool          `json:"enabled" yaml:"enabled"`
+	Repeat          int           `json:"repeat" yaml:"repeat"`
+	Targets         []TargetSpec  `json:"targets" yaml:"targets"`
+	Fleet           FleetDTO      `json:"fleet" yaml:"fleet"`
+	Cargo           CargoManifest `json:"cargo" yaml:"cargo"`
+	Schedule        Schedule      `json:"schedule" yaml:"schedule"`
+	RunLimit        int           `json:"run_limit" yaml:"run_limit"`
+	RunLimitType    string        `json:"run_limit_type" yaml:"run_limit_type"`
+	TargetStrategy  string        `json:"target_strategy" yaml:"target_strategy"`
+	SkipFailedAfter int           `json:"skip_failed_after" yaml:"skip_failed_after"`
+	Retry           RetryPolicy   `json:"retry" yaml:"retry"`
+}
+
+type TargetSpec struct {
+	Galaxy int  `json:"galaxy" yaml:"galaxy"`
+	System int  `json:"system" yaml:"system"`
+	Planet int  `json:"planet" yaml:"planet"`
+	IsMoon bool `json:"is_moon" yaml:"is_moon"`
+	Weight int  `json:"weight" yaml:"weight"`
+}
+
+func (t Task) ToSpec() TaskSpec {
+	spec := TaskSpec{
+		Name:            t.Name,
+		TaskType:        t.TaskType,
+		StartPlanetID:   t.StartPlanetID,
+		Enabled:         t.Enabled,
+		Repeat:          t.Repeat,
+		Targets:         make([]TargetSpec, 0, len(t.Targets)),
+		Fleet:           *t.Ships(),
+		Cargo:           t.Cargo,
+		Schedule:        t.Schedule,
+		RunLimit:        t.RunLimit,
+		RunLimitType:    t.RunLimitType,
+		TargetStrategy:  t.TargetStrategy,
+		SkipFailedAfter: t.SkipFailedAfter,
+		Retry:           t.Retry,
+	}
+	for _, target := range t.Targets {
+		spec.Targets = append(spec.Targets, TargetSpec{
+			Galaxy: target.Galaxy,
+			System: target.System,
+			Planet: target.Planet,
+			IsMoon: target.Is_moon,
+			Weight: target.Weight,
+		})
+	}
+	return spec
+}
+
+// ToTask builds a new task of accountID from the spec
+func (s TaskSpec) ToTask(accountID uint) Task {
+	task := Task{AccountID: accountID}
+	s.ApplyTo(&task)
+	return task
+}
+
+// ApplyTo overwrites the configuration of task with the spec, runtime state is kept
+func (s TaskSpec) ApplyTo(task *Task) {
+	task.Name = s.Name
+	task.TaskType = s.TaskType
+	task.StartPlanetID = s.StartPlanetID
+	task.Enabled = s.Enabled
+	task.Repeat = s.Repeat
+	task.TargetNum = len(s.Targets)
+	task.Fleet = s.Fleet.ToFleet()
+	task.Cargo = s.Cargo
+	task.Schedule = s.Schedule
+	task.RunLimit = s.RunLimit
+	task.RunLimitType = s.RunLimitType
+	task.TargetStrategy = s.TargetStrategy
+	task.SkipFailedAfter = s.SkipFailedAfter
+	task.Retry = s.Retry
+	task.Targets = make([]Target, 0, len(s.Targets))
+	for _, target := range s.Targets {
+		task.Targets = append(task.Targets, Target{
+			Galaxy:  target.Galaxy,
+			System:  target.System,
+			Planet:  target.Planet,
+			Is_moon: target.IsMoon,
+			Weight:  target.Weight,
+		})
+	}
+}
+
+func (dto FleetDTO) ToFleet() Fleet {
+	return Fleet{
+		LightFighter: dto.LightFighter,
+		HeavyFighter: dto.HeavyFighter,
+		Cruiser:      dto.Cruiser,
+		Battleship:   dto.Battleship,
+		Dreadnought:  dto.Dreadnought,
+		Destroyer:    dto.Destroyer,
+		Deathstar:    dto.Deathstar,
+		Bomber:       dto.Bomber,
+		Guardian:     dto.Guardian,
+		Satellite:    dto.Satellite,
+		Cargo:        dto.Cargo,
+	}
+}
+
+// Diff returns the json names of the fields that differ between s and other
+func (s TaskSpec) Diff(other TaskSpec) []string {
+	var fields []string
+	a, b := reflect.ValueOf(s), reflect.ValueOf(other)
+	for i := 0; i < a.NumField(); i++ {
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			name, _, _ := strings.Cut(a.Type().Field(i).Tag.Get("json"), ",")
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// ParseTaskDocument decodes a document in format and checks its version
+func ParseTaskDocument(data []byte, format string) (*TaskDocument, error) {
+	var doc TaskDocument
+	var err error
+	switch format {
+	case TASK_DOCUMENT_YAML:
+		err = yaml.UnmarshalStrict(data, &doc)
+	case TASK_DOCUMENT_JSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&doc)
+	default:
+		return nil, fmt.Errorf("unknown document format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if doc.Version != TASK_DOCUMENT_VERSION {
+		return nil, fmt.Errorf("unsupported document version %d, expected %d", doc.Version, TASK_DOCUMENT_VERSION)
+	}
+	return &doc, nil
+}
+
+// Encode encodes the document in format
+func (d *TaskDocument) Encode(format string) ([]byte, error) {
+	switch format {
+	case TASK_DOCUMENT_YAML:
+		return yaml.Marshal(d)
+	case TASK_DOCUMENT_JSON:
+		return json.MarshalIndent(d, "", "  ")
+	default:
+		return nil, fmt.Errorf("unknown document format %q", format)
+	}
+}
+
+// Enum TaskImportAction
+const (
+	TASK_IMPORT_CREATE    = "create"
+	TASK_IMPORT_UPDATE    = "update"
+	TASK_IMPORT_UNCHANGED = "unchanged"
+)
+
+// TaskImportChange is what an import does to one task of the document
+type TaskImportChange struct {
+	Name   string   `json:"name"`
+	Action string   `json:"action"`            // TASK_IMPORT_*
+	TaskID uint     `json:"task_id,omitempty"` // existing task, or the created one after a real import
+	Fields []string `json:"fields,omitempty"`  // changed fields of an update
+}
+
+type TaskImportResult struct {
+	DryRun  bool               `json:"dry_run"`
+	Changes []TaskImportChange `json:"changes"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTaskDocumentRoundTrip(t *testing.T) {
+	task := Task{
+		Name:          "farm",
+		TaskType:      TASKTYPE_ATTACK,
+		Enabled:       true,
+		StartPlanetID: 33624,
+		Repeat:        2,
+		Targets:       []Target{{Galaxy: 1, System: 2, Planet: 3, Weight: 5}, {Galaxy: 1, System: 2, Planet: 4, Is_moon: true}},
+		Fleet:         Fleet{LightFighter: 10, Cargo: 3},
+		Schedule:      Schedule{Type: SCHEDULE_TYPE_WINDOW, WindowStart: "08:00", WindowEnd: "22:00", IntervalMinutes: 30},
+		Retry:         RetryPolicy{MaxAttempts: 2, BackoffBase: 60},
+	}
+	doc := &TaskDocument{Version: TASK_DOCUMENT_VERSION, Tasks: []TaskSpec{task.ToSpec()}}
+	for _, format := range []string{TASK_DOCUMENT_YAML, TASK_DOCUMENT_JSON} {
+		data, err := doc.Encode(format)
+		if err != nil {
+			t.Fatalf("%s: Encode() error = %v", format, err)
+		}
+		got, err := ParseTaskDocument(data, format)
+		if err != nil {
+			t.Fatalf("%s: ParseTaskDocument() error = %v", format, err)
+		}
+		if !reflect.DeepEqual(got, doc) {
+			t.Errorf("%s: round trip = %+v, want %+v", format, got, doc)
+		}
+	}
+}
+
+func TestParseTaskDocumentRejects(t *testing.T) {
+	if _, err := ParseTaskDocument([]byte("version: 2\ntasks: []\n"), TASK_DOCUMENT_YAML); err == nil {
+		t.Error("accepted an unknown version")
+	}
+	if _, err := ParseTaskDocument([]byte("version: 1\ntasks:\n- nmae: typo\n"), TASK_DOCUMENT_YAML); err == nil {
+		t.Error("accepted an unknown field")
+	}
+}
+
+func TestTaskSpecDiff(t *testing.T) {
+	a := TaskSpec{Name: "farm", Repeat: 1, Targets: []TargetSpec{{Galaxy: 1}}}
+	b := a
+	b.Repeat = 2
+	b.Targets = []TargetSpec{{Galaxy: 2}}
+	if got := a.Diff(b); !reflect.DeepEqual(got, []string{"repeat", "targets"}) {
+		t.Errorf("Diff() = %v", got)
+	}
+	if got := a.Diff(a); got != nil {
+		t.Errorf("Diff() of equal specs = %v", got)
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Enum TaskStatus
+//
+//	ready -> queued -> dispatched -> running -> returned | failed | completed
+//
+// returned and failed are dispatched again like ready, cancelled waits for a resume.
+// A node may report before the generator marks a published task dispatched,
+// so queued moves on like dispatched.
+// insufficient_fleet waits for a fleet query or an update that makes the fleet fit.
+const (
+	TASK_STATUS_READY      = "ready"      // waiting for next_start
+	TASK_STATUS_QUEUED     = "queued"     // picked by the generator, not yet published
+	TASK_STATUS_DISPATCHED = "dispatched" // published, waiting for a node
+	TASK_STATUS_RUNNING    = "running"    // taken by a node
+	TASK_STATUS_RETURNED   = "returned"   // the last run succeeded
+	TASK_STATUS_FAILED     = "failed"     // the last run failed or timed out
+	TASK_STATUS_CANCELLED  = "cancelled"  // paused by the user
+	TASK_STATUS_COMPLETED  = "completed"  // terminal, the run limit is reached
+
+	TASK_STATUS_INSUFFICIENT_FLEET = "insufficient_fleet" // the start planet lacks the ships of the task
+)
+
+var taskTransitions = map[string][]string{
+	TASK_STATUS_READY:              {TASK_STATUS_QUEUED, TASK_STATUS_CANCELLED, TASK_STATUS_INSUFFICIENT_FLEET},
+	TASK_STATUS_QUEUED:             {TASK_STATUS_DISPATCHED, TASK_STATUS_READY, TASK_STATUS_RUNNING, TASK_STATUS_RETURNED, TASK_STATUS_FAILED, TASK_STATUS_COMPLETED},
+	TASK_STATUS_DISPATCHED:         {TASK_STATUS_RUNNING, TASK_STATUS_RETURNED, TASK_STATUS_FAILED, TASK_STATUS_COMPLETED},
+	TASK_STATUS_RUNNING:            {TASK_STATUS_RETURNED, TASK_STATUS_FAILED, TASK_STATUS_COMPLETED},
+	TASK_STATUS_RETURNED:           {TASK_STATUS_QUEUED, TASK_STATUS_CANCELLED, TASK_STATUS_INSUFFICIENT_FLEET},
+	TASK_STATUS_FAILED:             {TASK_STATUS_QUEUED, TASK_STATUS_CANCELLED, TASK_STATUS_INSUFFICIENT_FLEET},
+	TASK_STATUS_CANCELLED:          {TASK_STATUS_READY},
+	TASK_STATUS_INSUFFICIENT_FLEET: {TASK_STATUS_READY, TASK_STATUS_CANCELLED},
+}
+
+// normalizeStatus maps the statuses of tasks stored before the state machine
+func normalizeStatus(status string) string {
+	if status == "" || status == "waiting" {
+		return TASK_STATUS_READY
+	}
+	return status
+}
+
+// CanTransition reports whether a task may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range taskTransitions[normalizeStatus(from)] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDispatchable reports whether the generator may send a task in this status
+func IsDispatchable(status string) bool {
+	return CanTransition(status, TASK_STATUS_QUEUED)
+}
+
+// IsInFlight reports whether a run of a task in this status has not reported back yet
+func IsInFlight(status string) bool {
+	switch status {
+	case TASK_STATUS_QUEUED, TASK_STATUS_DISPATCHED, TASK_STATUS_RUNNING:
+		return true
+	}
+	return false
+}
+
+// TaskTransition is one status change of a task
+type TaskTransition struct {
+	gorm.Model
+	TaskID uint   `json:"task_id" gorm:"index"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+	UUID   string `json:"uuid"` // run that triggered the change, empty for user actions
+}
+
+// IllegalTransitionError is returned for a transition the state machine does not allow
+type IllegalTransitionError struct {
+	TaskID   uint
+	From, To string
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("task %d can not move from %q to %q", e.TaskID, e.From, e.To)
+}
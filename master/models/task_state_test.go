@@ -0,0 +1,34 @@
+package models
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{TASK_STATUS_READY, TASK_STATUS_QUEUED, true},
+		{"", TASK_STATUS_QUEUED, true}, // stored before the state machine
+		{TASK_STATUS_QUEUED, TASK_STATUS_DISPATCHED, true},
+		{TASK_STATUS_DISPATCHED, TASK_STATUS_RUNNING, true},
+		{TASK_STATUS_DISPATCHED, TASK_STATUS_RETURNED, true}, // the running message was lost
+		{TASK_STATUS_QUEUED, TASK_STATUS_RETURNED, true},     // the result came before the dispatched update
+		{TASK_STATUS_QUEUED, TASK_STATUS_RUNNING, true},
+		{TASK_STATUS_RUNNING, TASK_STATUS_COMPLETED, true},
+		{TASK_STATUS_FAILED, TASK_STATUS_QUEUED, true},
+		{TASK_STATUS_CANCELLED, TASK_STATUS_READY, true},
+		{TASK_STATUS_READY, TASK_STATUS_RUNNING, false},
+		{TASK_STATUS_RUNNING, TASK_STATUS_QUEUED, false},
+		{TASK_STATUS_RUNNING, TASK_STATUS_CANCELLED, false},
+		{TASK_STATUS_COMPLETED, TASK_STATUS_READY, false},
+		{TASK_STATUS_RETURNED, TASK_STATUS_RETURNED, false},
+		{TASK_STATUS_RETURNED, TASK_STATUS_INSUFFICIENT_FLEET, true},
+		{TASK_STATUS_INSUFFICIENT_FLEET, TASK_STATUS_READY, true},
+		{TASK_STATUS_INSUFFICIENT_FLEET, TASK_STATUS_QUEUED, false}, // waits for the fleet to fit again
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
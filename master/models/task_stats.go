@@ -0,0 +1,43 @@
+package models
+
+// TaskStats aggregates the runs recorded in task logs
+type TaskStats struct {
+	Dispatched    int64   `json:"dispatched"`
+	Succeeded     int64   `json:"succeeded"`
+	Failed        int64   `json:"failed"`
+	TimedOut      int64   `json:"timed_out"`
+	Running       int64   `json:"running"`
+	SuccessRate   float64 `json:"success_rate"`    // of the finished runs
+	FailureRate   float64 `json:"failure_rate"`    // of the finished runs, timeouts included
+	MeanRoundTrip float64 `json:"mean_round_trip"` // seconds from dispatch to fleet back, successful runs only
+}
+
+// SetRates fills the rates from the counts
+func (s *TaskStats) SetRates() {
+	finished := s.Succeeded + s.Failed + s.TimedOut
+	if finished == 0 {
+		s.SuccessRate, s.FailureRate = 0, 0
+		return
+	}
+	s.SuccessRate = float64(s.Succeeded) / float64(finished)
+	s.FailureRate = float64(s.Failed+s.TimedOut) / float64(finished)
+}
+
+type DailyTaskStats struct {
+	Day string `json:"day"` // YYYY-MM-DD, by dispatch time
+	TaskStats
+}
+
+type FailureReason struct {
+	ErrMsg string `json:"err_msg"`
+	Count  int64  `json:"count"`
+}
+
+// TaskStatsReport is the statistics of a task or an account over a time range
+type TaskStatsReport struct {
+	Since          int64            `json:"since"`
+	Until          int64            `json:"until"`
+	Summary        TaskStats        `json:"summary"`
+	Daily          []DailyTaskStats `json:"daily"`
+	FailureReasons []FailureReason  `json:"failure_reasons"` // most frequent first
+}
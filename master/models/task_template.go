@@ -0,0 +1,21 @@
+package models
+
+import "gorm.io/gorm"
+
+// TaskTemplate is a task configuration of a user that can be stamped out on
+// several accounts. Every update bumps Version, instances remember the version
+// they were built from in Task.TemplateVersion.
+type TaskTemplate struct {
+	gorm.Model
+	UserID  uint     `json:"user_id" gorm:"index"`
+	Name    string   `json:"name"`
+	Version int      `json:"version"`
+	Spec    TaskSpec `json:"spec" gorm:"serializer:json;type:text"` // Spec.Name names the instances
+}
+
+// TemplateInstance is the account an instance of a template runs on and the
+// planet its fleets start from
+type TemplateInstance struct {
+	AccountID     uint `json:"account_id"`
+	StartPlanetID uint `json:"start_planet_id"` // see QueryPlanetID
+}
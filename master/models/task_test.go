@@ -0,0 +1,24 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSingleTaskResponsePayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		response SingleTaskResponse
+		want     string
+	}{
+		{"data", SingleTaskResponse{Msg: "ignored", Data: json.RawMessage(`{"outcome":"win"}`)}, `{"outcome":"win"}`},
+		{"null data falls back to msg", SingleTaskResponse{Msg: `{"metal":1}`, Data: json.RawMessage(`null`)}, `{"metal":1}`},
+		{"msg only", SingleTaskResponse{Msg: `{"metal":1}`}, `{"metal":1}`},
+		{"nothing", SingleTaskResponse{}, ""},
+	}
+	for _, tt := range tests {
+		if got := string(tt.response.Payload()); got != tt.want {
+			t.Errorf("%s: Payload() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ScanRange is the part of a galaxy a scan task walks, both systems included
+type ScanRange struct {
+	Galaxy     int `json:"galaxy"`
+	SystemFrom int `json:"system_from"`
+	SystemTo   int `json:"system_to"`
+}
+
+func (r ScanRange) Validate(maxSystems int) error {
+	if r.Galaxy < 1 || r.SystemFrom < 1 || r.SystemTo < 1 {
+		return errors.New("galaxy and systems must be positive")
+	}
+	if r.SystemFrom > r.SystemTo {
+		return errors.New("system_from must not be after system_to")
+	}
+	if r.SystemTo-r.SystemFrom+1 > maxSystems {
+		return errors.New("too many systems in one scan")
+	}
+	return nil
+}
+
+// PlanetActivity are the flags the galaxy view shows for a player
+type PlanetActivity struct {
+	Inactive     bool `json:"inactive"`      // inactive for a week
+	LongInactive bool `json:"long_inactive"` // inactive for four weeks
+	Vacation     bool `json:"vacation"`
+	Banned       bool `json:"banned"`
+	Noob         bool `json:"noob"`     // under noob protection
+	Strong       bool `json:"strong"`   // too strong to be attacked
+	Activity     int  `json:"activity"` // minutes since the last activity on the planet, 0 when none shown
+}
+
+// ScannedPlanet is one occupied position in a galaxy scan result
+type ScannedPlanet struct {
+	System     int            `json:"system"`
+	Position   int            `json:"position"`
+	PlanetID   int            `json:"planet_id"`
+	PlanetName string         `json:"planet_name"`
+	PlayerID   int            `json:"player_id"`
+	PlayerName string         `json:"player_name"`
+	Alliance   string         `json:"alliance"`
+	HasMoon    bool           `json:"has_moon"`
+	MoonID     int            `json:"moon_id"`
+	Activity   PlanetActivity `json:"activity"`
+}
+
+// GalaxyScanResult is the payload of a successful galaxy scan result
+type GalaxyScanResult struct {
+	ScanRange
+	Planets []ScannedPlanet `json:"planets"`
+}
+
+// UniversePlanet is the last known occupant of a position of a server,
+// written by the galaxy scan task. Moons are flagged on their planet.
+type UniversePlanet struct {
+	ID         uint           `json:"id" gorm:"primarykey"`
+	Server     string         `json:"server" gorm:"type:varchar(100);uniqueIndex:idx_universe_position,priority:1"`
+	Star       Star           `json:"star" gorm:"embedded"`
+	PlanetName string         `json:"planet_name"`
+	PlayerID   int            `json:"player_id" gorm:"index"`
+	PlayerName string         `json:"player_name"`
+	Alliance   string         `json:"alliance"`
+	HasMoon    bool           `json:"has_moon"`
+	MoonID     int            `json:"moon_id"`
+	Activity   PlanetActivity `json:"activity" gorm:"embedded"`
+	UUID       string         `json:"uuid"` // scan that reported it
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// UniverseFilter selects planets of the universe catalog of a server
+type UniverseFilter struct {
+	Galaxy     int
+	SystemFrom int // 0 for no bound
+	SystemTo   int // 0 for no bound
+	Player     string
+	Alliance   string
+	Inactive   bool // only inactive or long inactive players
+	Page       int
+	PageSize   int
+}
@@ -0,0 +1,22 @@
+package models
+
+import "testing"
+
+func TestScanRangeValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		scan    ScanRange
+		wantErr bool
+	}{
+		{"one system", ScanRange{Galaxy: 1, SystemFrom: 10, SystemTo: 10}, false},
+		{"full range", ScanRange{Galaxy: 2, SystemFrom: 1, SystemTo: 100}, false},
+		{"too many systems", ScanRange{Galaxy: 2, SystemFrom: 1, SystemTo: 101}, true},
+		{"reversed", ScanRange{Galaxy: 1, SystemFrom: 20, SystemTo: 10}, true},
+		{"no galaxy", ScanRange{SystemFrom: 1, SystemTo: 2}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.scan.Validate(100); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
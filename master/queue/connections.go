@@ -82,6 +82,8 @@ func InitDeclare() {
 	DeclareQueue(rabbitMQConnection.Channel, config.RESULT_QUEUE_NAME)
 	log.Info(fmt.Sprintf("DeclareQueue %s", config.INSTANT_QUEUE_NAME))
 	DeclareQueue(rabbitMQConnection.Channel, config.INSTANT_QUEUE_NAME)
+	log.Info(fmt.Sprintf("DeclareQueue %s", config.RESULT_DLQ_NAME))
+	DeclareQueue(rabbitMQConnection.Channel, config.RESULT_DLQ_NAME)
 	log.Info("BindQueue")
 	log.Info(fmt.Sprintf("BindQueue %s %s %s", config.TASK_QUEUE_NAME, config.TASK_QUEUE_NAME, config.DELAYED_EXCHANGE_NAME))
 	BindQueue(rabbitMQConnection.Channel, config.TASK_QUEUE_NAME, config.TASK_QUEUE_NAME, config.DELAYED_EXCHANGE_NAME)
@@ -169,6 +171,34 @@ func (rmq *RabbitMQConnection) SendNormalMessage(body string, routingKey string)
 	return fmt.Errorf("failed to send normal message after %d attempts", maxRetries)
 }
 
+// SendMessageWithHeaders publishes a persistent message with headers to a queue
+func (rmq *RabbitMQConnection) SendMessageWithHeaders(body []byte, routingKey string, headers amqp.Table) error {
+	rmq.mutex.Lock()
+	defer rmq.mutex.Unlock()
+
+	for i := 0; i < maxRetries; i++ {
+		err := rmq.Channel.Publish(
+			"",         // exchange
+			routingKey, // routing key
+			false,      // mandatory
+			false,      // immediate
+			amqp.Publishing{
+				ContentType:  "text/plain",
+				Body:         body,
+				Headers:      headers,
+				DeliveryMode: amqp.Persistent,
+			})
+
+		if err != nil {
+			log.Info("Failed to send message with headers: %v, retry: %d", zap.Error(err), zap.Int("retry", i+1))
+			rmq.reconnect()
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to send message with headers after %d attempts", maxRetries)
+}
+
 // SendDelayedMessage 添加重连机制
 func (rmq *RabbitMQConnection) SendDelayedMessage(body string, routingKey string, delay time.Duration) error {
 	rmq.mutex.Lock()
@@ -224,6 +254,16 @@ func (rmq *RabbitMQConnection) ensureConnected() error {
 // ConsumeNormalMessage 添加重连机制
 // 修改消费者的通知处理
 func (rmq *RabbitMQConnection) ConsumeNormalMessage(queueName string) (<-chan amqp.Delivery, error) {
+	return rmq.consume(queueName, true, 0)
+}
+
+// ConsumeAckMessage consumes without auto-ack, every delivery must be acked or nacked.
+// RabbitMQ stops delivering once prefetch deliveries of this consumer are unacked, 0 means no limit.
+func (rmq *RabbitMQConnection) ConsumeAckMessage(queueName string, prefetch int) (<-chan amqp.Delivery, error) {
+	return rmq.consume(queueName, false, prefetch)
+}
+
+func (rmq *RabbitMQConnection) consume(queueName string, autoAck bool, prefetch int) (<-chan amqp.Delivery, error) {
 	deliveries := make(chan amqp.Delivery)
 
 	go func() {
@@ -241,10 +281,26 @@ func (rmq *RabbitMQConnection) ConsumeNormalMessage(queueName string) (<-chan am
 			}
 			rmq.reconnectingMux.Unlock()
 
-			msgs, err := rmq.Channel.Consume(
+			// Every consumer has its own channel, the prefetch and the acks of
+			// one consumer never hold back another
+			ch, err := rmq.Conn.Channel()
+			if err != nil {
+				log.Info("Failed to open consumer channel", zap.Error(err))
+				rmq.reconnect()
+				continue
+			}
+			if prefetch > 0 {
+				if err := ch.Qos(prefetch, 0, false); err != nil {
+					log.Info("Failed to set prefetch", zap.Error(err))
+					ch.Close()
+					time.Sleep(reconnectDelay)
+					continue
+				}
+			}
+			msgs, err := ch.Consume(
 				queueName,
 				"",
-				true,
+				autoAck,
 				false,
 				false,
 				false,
@@ -253,11 +309,12 @@ func (rmq *RabbitMQConnection) ConsumeNormalMessage(queueName string) (<-chan am
 
 			if err != nil {
 				log.Info("Failed to consume message", zap.Error(err))
-				rmq.reconnect()
+				ch.Close()
+				time.Sleep(reconnectDelay)
 				continue
 			}
 
-			chanClose := rmq.Channel.NotifyClose(make(chan *amqp.Error))
+			chanClose := ch.NotifyClose(make(chan *amqp.Error))
 			connClose := rmq.Conn.NotifyClose(make(chan *amqp.Error))
 
 			for {
@@ -268,8 +325,8 @@ func (rmq *RabbitMQConnection) ConsumeNormalMessage(queueName string) (<-chan am
 					}
 					deliveries <- d
 				case <-chanClose:
-					log.Info("Channel closed, reconnecting...")
-					rmq.reconnect()
+					// The connection may still be open, a new channel is enough
+					log.Info("Consumer channel closed, reopening...")
 					goto RECONNECT
 				case <-connClose:
 					log.Info("Connection closed, reconnecting...")
@@ -3,8 +3,12 @@ package routes
 import (
 	"GalaxyEmpireWeb/api"
 	"GalaxyEmpireWeb/api/account"
+	"GalaxyEmpireWeb/api/admin"
 	"GalaxyEmpireWeb/api/auth"
+	"GalaxyEmpireWeb/api/fleet"
 	"GalaxyEmpireWeb/api/task"
+	"GalaxyEmpireWeb/api/template"
+	"GalaxyEmpireWeb/api/universe"
 	"GalaxyEmpireWeb/api/user"
 	"GalaxyEmpireWeb/docs"
 	"GalaxyEmpireWeb/middleware"
@@ -55,16 +59,67 @@ func RegisterRoutes() *gin.Engine {
 		a.DELETE("", account.DeleteAccount)
 		a.POST("/check", account.CheckAccountAvailable)
 		a.GET("/check/:uuid", account.CheckAccountByUUID)
+		a.GET("/:id/logs", task.GetAccountLogs)
+		a.GET("/:id/stats", task.GetAccountStats)
+		a.GET("/:id/fleet", fleet.GetFleetInventory)
+		a.POST("/:id/fleet/query", fleet.QueryFleet)
+		a.GET("/:id/spy-reports", task.GetAccountSpyReports)
+		a.POST("/:id/universe/scan", universe.ScanGalaxy)
+		a.GET("/:id/universe/:galaxy", universe.GetGalaxy)
+		a.GET("/:id/universe/:galaxy/:system", universe.GetSystem)
 	}
 	t := v1.Group("/task")
 	{
 		t.GET("/:id", task.GetTaskByID)
 		t.GET("/account/:id", task.GetTaskByAccountID)
+		t.GET("/preview", task.PreviewTasks)
+		t.GET("/export", task.ExportTasks)
+		t.POST("/import", task.ImportTasks)
 		t.POST("", task.AddTask)
 		t.DELETE("", task.DeleteTask)
 		t.PUT("", task.UpdateTask)
+		t.POST("/:id/pause", task.PauseTask)
+		t.POST("/:id/resume", task.ResumeTask)
+		t.POST("/:id/run", task.RunTaskNow)
+		t.GET("/:id/history", task.GetTaskHistory)
+		t.GET("/:id/logs", task.GetTaskLogs)
+		t.GET("/:id/stats", task.GetTaskStats)
+		t.GET("/:id/transports", task.GetTaskTransports)
+		t.GET("/:id/battle-reports", task.GetTaskBattleReports)
+		t.GET("/:id/expedition-reports", task.GetTaskExpeditionReports)
+		t.GET("/:id/target-proposals", task.GetTargetProposals)
+		t.POST("/:id/targets", task.AcceptTargets)
 	}
 	task.RegisterPlanetRoutes(t)
+	v1.GET("/spy-report/:id", task.GetSpyReport)
+
+	tpl := v1.Group("/template")
+	{
+		tpl.GET("", template.ListTemplates)
+		tpl.POST("", template.CreateTemplate)
+		tpl.GET("/:id", template.GetTemplate)
+		tpl.PUT("/:id", template.UpdateTemplate)
+		tpl.DELETE("/:id", template.DeleteTemplate)
+		tpl.POST("/:id/instantiate", template.InstantiateTemplate)
+		tpl.POST("/:id/apply", template.ApplyTemplate)
+	}
+
+	fp := v1.Group("/fleet-preset")
+	{
+		fp.GET("", fleet.ListFleetPresets)
+		fp.POST("", fleet.CreateFleetPreset)
+		fp.GET("/:id", fleet.GetFleetPreset)
+		fp.PUT("/:id", fleet.UpdateFleetPreset)
+		fp.DELETE("/:id", fleet.DeleteFleetPreset)
+		fp.GET("/:id/tasks", fleet.GetFleetPresetTasks)
+	}
+
+	adm := v1.Group("/admin", middleware.AdminMiddleware())
+	{
+		adm.GET("/dead-letter", admin.ListDeadLetters)
+		adm.GET("/dead-letter/:id", admin.GetDeadLetter)
+		adm.POST("/dead-letter/:id/replay", admin.ReplayDeadLetter)
+	}
 
 	return r
 }
@@ -0,0 +1,161 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/config"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Headers set on dead-lettered messages
+const (
+	headerFailureReason = "x-failure-reason"
+	headerOriginalQueue = "x-original-queue"
+	headerFailedAt      = "x-failed-at"
+)
+
+// deadLetter moves a failed delivery to the dead-letter queue with the reason in
+// its headers. The delivery is requeued when the dead-letter queue is unreachable.
+func (ts *taskService) deadLetter(msg amqp.Delivery, queueName, reason string) {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[headerFailureReason] = reason
+	headers[headerOriginalQueue] = queueName
+	headers[headerFailedAt] = time.Now().Unix()
+
+	if err := ts.MQ.SendMessageWithHeaders(msg.Body, config.RESULT_DLQ_NAME, headers); err != nil {
+		log.Error("[TaskService::DeadLetter] failed to publish dead letter, requeueing",
+			zap.String("queue", queueName),
+			zap.String("reason", reason),
+			zap.Error(err))
+		if err := msg.Nack(false, true); err != nil {
+			log.Error("[TaskService::DeadLetter] failed to nack message", zap.Error(err))
+		}
+		return
+	}
+	log.Warn("[TaskService::DeadLetter] message dead-lettered",
+		zap.String("queue", queueName),
+		zap.String("reason", reason))
+	if err := msg.Nack(false, false); err != nil {
+		log.Error("[TaskService::DeadLetter] failed to nack message", zap.Error(err))
+	}
+}
+
+// ListenFromDeadLetterQueue archives dead-lettered messages into the dead_letters table
+func (ts *taskService) ListenFromDeadLetterQueue(queueName string) {
+	const reconnectDelay = 5 * time.Second
+
+	log.Info("Listening from dead-letter queue", zap.String("queueName", queueName))
+
+	for {
+		deliveries, err := ts.MQ.ConsumeAckMessage(queueName, 1)
+		if err != nil {
+			log.Error("Failed to consume message from dead-letter queue",
+				zap.Error(err),
+				zap.String("queue", queueName))
+			time.Sleep(reconnectDelay)
+			continue
+		}
+
+		for msg := range deliveries {
+			if err := ts.archiveDeadLetter(msg); err != nil {
+				log.Error("[TaskService::DeadLetter] failed to archive dead letter", zap.Error(err))
+				msg.Nack(false, true)
+				time.Sleep(reconnectDelay)
+				continue
+			}
+			msg.Ack(false)
+		}
+
+		log.Warn("Message channel closed, attempting to reconnect...",
+			zap.String("queue", queueName))
+		time.Sleep(reconnectDelay)
+	}
+}
+
+func (ts *taskService) archiveDeadLetter(msg amqp.Delivery) error {
+	deadLetter := models.DeadLetter{Body: string(msg.Body)}
+	deadLetter.Reason, _ = msg.Headers[headerFailureReason].(string)
+	deadLetter.Queue, _ = msg.Headers[headerOriginalQueue].(string)
+	var response models.SingleTaskResponse
+	if err := json.Unmarshal(msg.Body, &response); err == nil {
+		deadLetter.UUID = response.UUID
+		deadLetter.TaskID = response.TaskID
+	}
+	return ts.DB.Create(&deadLetter).Error
+}
+
+// ListDeadLetters returns archived dead letters, newest first
+func (ts *taskService) ListDeadLetters(ctx context.Context, page, pageSize int) ([]models.DeadLetter, int64, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	log.Info("[TaskService] ListDeadLetters", zap.String("traceID", traceID), zap.Int("page", page), zap.Int("pageSize", pageSize))
+	var total int64
+	if err := ts.DB.Model(&models.DeadLetter{}).Count(&total).Error; err != nil {
+		log.Error("[TaskService] ListDeadLetters", zap.String("traceID", traceID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Count Dead Letter Error", err)
+	}
+	var deadLetters []models.DeadLetter
+	if err := ts.DB.Omit("body").Order("id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&deadLetters).Error; err != nil {
+		log.Error("[TaskService] ListDeadLetters", zap.String("traceID", traceID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Get Dead Letter Error", err)
+	}
+	return deadLetters, total, nil
+}
+
+// GetDeadLetter returns one dead letter with its message body
+func (ts *taskService) GetDeadLetter(ctx context.Context, id uint) (*models.DeadLetter, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	log.Info("[TaskService] GetDeadLetter", zap.String("traceID", traceID), zap.Uint("id", id))
+	var deadLetter models.DeadLetter
+	if err := ts.DB.First(&deadLetter, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewServiceError(http.StatusNotFound, "Dead Letter Not Found", err)
+		}
+		log.Error("[TaskService] GetDeadLetter", zap.String("traceID", traceID), zap.Uint("id", id), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Dead Letter Error", err)
+	}
+	return &deadLetter, nil
+}
+
+// ReplayDeadLetter publishes a dead letter to its original queue again.
+// A replay that fails again comes back as a new dead letter.
+func (ts *taskService) ReplayDeadLetter(ctx context.Context, id uint) (*models.DeadLetter, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	deadLetter, serviceErr := ts.GetDeadLetter(ctx, id)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	queueName := deadLetter.Queue
+	if queueName == "" {
+		queueName = config.RESULT_QUEUE_NAME
+	}
+	if err := ts.MQ.SendNormalMessage(deadLetter.Body, queueName); err != nil {
+		log.Error("[TaskService] ReplayDeadLetter", zap.String("traceID", traceID), zap.Uint("id", id), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Publish Dead Letter Error", err)
+	}
+	now := time.Now()
+	deadLetter.ReplayCount++
+	deadLetter.ReplayedAt = &now
+	if err := ts.DB.Model(deadLetter).Updates(map[string]interface{}{
+		"replay_count": deadLetter.ReplayCount,
+		"replayed_at":  now,
+	}).Error; err != nil {
+		log.Error("[TaskService] ReplayDeadLetter", zap.String("traceID", traceID), zap.Uint("id", id), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Update Dead Letter Error", err)
+	}
+	log.Info("[TaskService] ReplayDeadLetter Succeed", zap.String("traceID", traceID), zap.Uint("id", id),
+		zap.String("uuid", deadLetter.UUID), zap.String("queue", queueName))
+	return deadLetter, nil
+}
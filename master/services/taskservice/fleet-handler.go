@@ -0,0 +1,203 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/config"
+	"GalaxyEmpireWeb/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// fleetHandler handles the scheduled fleet missions, attack and explore
+type fleetHandler struct{}
+
+func (h *fleetHandler) Validate(task *models.Task) error {
+	if len(task.Targets) == 0 {
+		return errors.New("no targets available for task")
+	}
+	return nil
+}
+
+func (h *fleetHandler) BuildRequest(task *models.Task, account *models.Account) (*models.SingleTaskRequest, error) {
+	return task.ToSingleTaskRequest(account)
+}
+
+func (h *fleetHandler) RetryPolicy() models.RetryPolicy {
+	return defaultRetryPolicy
+}
+
+func (h *fleetHandler) Timeout() time.Duration {
+	return config.FLEET_TASK_TIMEOUT
+}
+
+func (h *fleetHandler) HandleResult(tx *gorm.DB, response *models.SingleTaskResponse) (*models.Task, error) {
+	locked, err := lockTask(tx, response.TaskID)
+	if err != nil {
+		log.Error("[fleetHandler::HandleResult] failed to load task",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", response.TaskID),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to load task: %w", err)
+	}
+	task := *locked
+
+	target, err := recordTargetResult(tx, response)
+	if err != nil {
+		log.Error("[fleetHandler::HandleResult] failed to record target stats",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", task.ID),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to record target stats: %w", err)
+	}
+
+	// Finished runs are dropped before and unpublished runs close their log, so
+	// the task is in flight here. The guard keeps a stray result from moving it.
+	if !models.IsInFlight(task.Status) {
+		log.Warn("[fleetHandler::HandleResult] result of a task not in flight, status kept",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", task.ID),
+			zap.String("status", task.Status))
+		return &task, nil
+	}
+
+	if response.Status != models.TASK_RESULT_SUCCESS {
+		// 即使任务失败也要更新任务状态和下次执行时间
+		if err := applyRunFailure(tx, &task, task.Retry.WithDefaults(h.RetryPolicy()), target, response.UUID, response.ErrMsg); err != nil {
+			log.Error("[fleetHandler::HandleResult] failed to update task status",
+				zap.String("uuid", response.UUID),
+				zap.Uint("task_id", task.ID),
+				zap.Error(err))
+			return nil, err
+		}
+		log.Warn("[fleetHandler::HandleResult] task execution failed but status updated",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", task.ID),
+			zap.Int("status", response.Status),
+			zap.Int("consecutive_failures", task.ConsecutiveFailures),
+			zap.Time("next_start", time.Unix(task.NextStart, 0)),
+			zap.String("err_msg", response.ErrMsg))
+		return &task, nil
+	}
+
+	log.Info("[fleetHandler::HandleResult] task succeeded",
+		zap.String("uuid", response.UUID),
+		zap.Uint("task_id", task.ID),
+		zap.Int("task_type", response.TaskType))
+
+	// Update task status, the next launch follows the task schedule
+	nextStart := task.Schedule.NextAfterReturn(time.Unix(response.BackTimestamp, 0),
+		time.Duration(config.TASK_DELAY)*time.Second)
+	status, reason := models.TASK_STATUS_RETURNED, "fleet returned"
+	task.NextStart = nextStart.Unix()
+	task.RunCount++
+
+	if task.RunLimit > 0 {
+		var targetNum int64
+		if err := tx.Model(&models.Target{}).Where("task_id = ?", task.ID).Count(&targetNum).Error; err != nil {
+			log.Error("[fleetHandler::HandleResult] failed to count targets",
+				zap.String("uuid", response.UUID),
+				zap.Uint("task_id", task.ID),
+				zap.Error(err))
+			return nil, fmt.Errorf("failed to count targets: %w", err)
+		}
+		if task.RunLimitReached(task.RunCount, int(targetNum)) {
+			log.Info("[fleetHandler::HandleResult] run limit reached, task completed",
+				zap.Uint("task_id", task.ID),
+				zap.Int("run_count", task.RunCount),
+				zap.Int("run_limit", task.RunLimit),
+				zap.String("run_limit_type", task.RunLimitType))
+			status, reason = models.TASK_STATUS_COMPLETED, "run limit reached"
+		}
+	}
+
+	if err := transitionTask(tx, &task, status, reason, response.UUID, map[string]interface{}{
+		"next_start":           task.NextStart,
+		"run_count":            task.RunCount,
+		"consecutive_failures": 0,
+		"retry_target_id":      0,
+	}); err != nil {
+		log.Error("[fleetHandler::HandleResult] failed to update task",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", task.ID),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+	return &task, nil
+}
+
+// applyRunFailure schedules the retry of a failed run under policy and
+// disables the task once it failed too many times in a row.
+// target is the target of the failed run, nil when unknown.
+func applyRunFailure(tx *gorm.DB, task *models.Task, policy models.RetryPolicy, target *models.Target, uuid, errMsg string) error {
+	task.ConsecutiveFailures++
+	task.NextStart = task.Schedule.Align(time.Now().Add(policy.Backoff(task.ConsecutiveFailures))).Unix()
+
+	// Keep hitting the same target until it used up its attempts
+	task.RetryTargetID = 0
+	if target != nil && target.ConsecutiveFailures < policy.MaxAttempts {
+		task.RetryTargetID = target.ID
+	}
+
+	updates := map[string]interface{}{
+		"next_start":           task.NextStart,
+		"consecutive_failures": task.ConsecutiveFailures,
+		"retry_target_id":      task.RetryTargetID,
+	}
+	if policy.ShouldDisable(task.ConsecutiveFailures) {
+		task.Enabled = false
+		task.DisabledReason = fmt.Sprintf("disabled after %d consecutive failures, last error: %s",
+			task.ConsecutiveFailures, errMsg)
+		updates["enabled"] = false
+		updates["disabled_reason"] = task.DisabledReason
+		log.Warn("[TaskService] task disabled by retry policy",
+			zap.Uint("task_id", task.ID),
+			zap.String("task_name", task.Name),
+			zap.Int("consecutive_failures", task.ConsecutiveFailures),
+			zap.String("err_msg", errMsg))
+	}
+	reason := errMsg
+	if reason == "" {
+		reason = "run failed"
+	}
+	return transitionTask(tx, task, models.TASK_STATUS_FAILED, reason, uuid, updates)
+}
+
+// recordTargetResult updates the hit and failure stats of the target the run
+// was sent to and returns it, nil when the run had no target.
+func recordTargetResult(tx *gorm.DB, response *models.SingleTaskResponse) (*models.Target, error) {
+	var taskLog models.TaskLog
+	if err := tx.Select("target_id").Where("uuid = ?", response.UUID).First(&taskLog).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // dispatched before target stats existed
+		}
+		return nil, err
+	}
+	if taskLog.TargetID == 0 {
+		return nil, nil
+	}
+	updates := map[string]interface{}{
+		"failure_count":        gorm.Expr("failure_count + 1"),
+		"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+	}
+	if response.Status == models.TASK_RESULT_SUCCESS {
+		updates = map[string]interface{}{
+			"hit_count":            gorm.Expr("hit_count + 1"),
+			"consecutive_failures": 0,
+			"last_hit_at":          time.Now().Unix(),
+		}
+	}
+	if err := tx.Model(&models.Target{}).Where("id = ?", taskLog.TargetID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	var target models.Target
+	if err := tx.First(&target, taskLog.TargetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // target removed meanwhile
+		}
+		return nil, err
+	}
+	return &target, nil
+}
@@ -0,0 +1,122 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// QueryFleet asks a node for the ship counts of every planet of an account.
+// The result replaces the fleet inventory, it returns the uuid of the query.
+func (ts *taskService) QueryFleet(ctx context.Context, accountID uint) (string, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] QueryFleet", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("accountID", accountID))
+
+	if serviceErr := ts.checkAccountPermission(ctx, accountID, "write"); serviceErr != nil {
+		return "", serviceErr
+	}
+	var account models.Account
+	if err := ts.DB.First(&account, accountID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", utils.NewServiceError(http.StatusNotFound, "Account Not Found", err)
+		}
+		log.Error("[TaskService] QueryFleet", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Get Account Error", err)
+	}
+	return ts.dispatchInstant(ctx, &account, &models.SingleTaskRequest{TaskType: models.TASKTYPE_QUERY_FLEET})
+}
+
+// GetFleetInventory returns the last known ship counts of the planets of an account
+func (ts *taskService) GetFleetInventory(ctx context.Context, accountID uint) ([]models.FleetInventory, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] GetFleetInventory", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("accountID", accountID))
+
+	if serviceErr := ts.checkAccountPermission(ctx, accountID, "read"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	var inventory []models.FleetInventory
+	if err := ts.DB.Where("account_id = ?", accountID).Order("planet_id").Find(&inventory).Error; err != nil {
+		log.Error("[TaskService] GetFleetInventory", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Fleet Inventory Error", err)
+	}
+	return inventory, nil
+}
+
+// loadFleetInventory returns the inventory of an account by planet id
+func loadFleetInventory(db *gorm.DB, accountID uint) (map[uint]models.FleetInventory, error) {
+	var rows []models.FleetInventory
+	if err := db.Where("account_id = ?", accountID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	inventory := make(map[uint]models.FleetInventory, len(rows))
+	for _, row := range rows {
+		inventory[row.PlanetID] = row
+	}
+	return inventory, nil
+}
+
+// fleetShortage returns why the start planet can not send the fleet of a task,
+// "" when it can or when its ships were never queried
+func fleetShortage(task *models.Task, inventory map[uint]models.FleetInventory) string {
+	planet, ok := inventory[task.StartPlanetID]
+	if !ok {
+		return ""
+	}
+	missing := task.Ships().Shortage(planet.Ships)
+	if len(missing) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("insufficient fleet: %s", strings.Join(missing, ", "))
+}
+
+// startPlanetError returns why a task may not start from a planet: it is
+// required and must be a planet of the account as the last fleet query knows them
+func startPlanetError(planetID uint, inventory map[uint]models.FleetInventory) error {
+	if planetID == 0 {
+		return errors.New("start_planet_id is required")
+	}
+	if len(inventory) == 0 {
+		return errors.New("planets of the account unknown, query the fleet first")
+	}
+	if _, ok := inventory[planetID]; !ok {
+		return fmt.Errorf("planet %d is not a planet of the account", planetID)
+	}
+	return nil
+}
+
+// accountPlanetsError checks the planets a task uses against those of its
+// account. The start planet is checked when newStart is set, a task created
+// or moved to another planet, transport targets always.
+func accountPlanetsError(task *models.Task, newStart bool, inventory map[uint]models.FleetInventory) error {
+	if newStart {
+		if err := startPlanetError(task.StartPlanetID, inventory); err != nil {
+			return err
+		}
+	}
+	return ownTargetsError(task, inventory)
+}
+
+// checkAccountPlanets loads the planets of the account of a task and checks
+// the task against them, see accountPlanetsError
+func (ts *taskService) checkAccountPlanets(ctx context.Context, db *gorm.DB, task *models.Task, newStart bool) *utils.ServiceError {
+	traceID := utils.TraceIDFromContext(ctx)
+	inventory, err := loadFleetInventory(db, task.AccountID)
+	if err != nil {
+		log.Error("[TaskService] failed to get fleet inventory", zap.String("traceID", traceID), zap.Uint("accountID", task.AccountID), zap.Error(err))
+		return utils.NewServiceError(http.StatusInternalServerError, "Get Fleet Inventory Error", err)
+	}
+	if err := accountPlanetsError(task, newStart, inventory); err != nil {
+		log.Warn("[TaskService] task uses planets foreign to the account", zap.String("traceID", traceID), zap.Uint("taskID", task.ID), zap.Error(err))
+		return utils.NewServiceError(http.StatusBadRequest, "Invalid Task", err)
+	}
+	return nil
+}
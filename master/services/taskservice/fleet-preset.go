@@ -0,0 +1,191 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// CreateFleetPreset saves a preset owned by the user in ctx, restricted to one
+// of their accounts when AccountID is set
+func (ts *taskService) CreateFleetPreset(ctx context.Context, preset *models.FleetPreset) *utils.ServiceError {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] CreateFleetPreset", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.String("name", preset.Name))
+
+	if preset.Name == "" {
+		return utils.NewServiceError(http.StatusBadRequest, "Invalid Fleet Preset", errors.New("name is required"))
+	}
+	if preset.AccountID != 0 {
+		if serviceErr := ts.checkAccountPermission(ctx, preset.AccountID, "write"); serviceErr != nil {
+			return serviceErr
+		}
+	}
+	preset.ID = 0
+	preset.UserID = userID
+	if err := ts.DB.Create(preset).Error; err != nil {
+		log.Error("[TaskService] CreateFleetPreset", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		return utils.NewServiceError(http.StatusInternalServerError, "Create Fleet Preset Error", err)
+	}
+	return nil
+}
+
+// ListFleetPresets returns the presets of the user in ctx, only those usable
+// by accountID when it is not 0
+func (ts *taskService) ListFleetPresets(ctx context.Context, accountID uint) ([]models.FleetPreset, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ListFleetPresets", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("accountID", accountID))
+
+	query := ts.DB.Where("user_id = ?", userID)
+	if accountID != 0 {
+		query = query.Where("account_id IN ?", []uint{0, accountID})
+	}
+	var presets []models.FleetPreset
+	if err := query.Order("id").Find(&presets).Error; err != nil {
+		log.Error("[TaskService] ListFleetPresets", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Fleet Preset Error", err)
+	}
+	return presets, nil
+}
+
+// GetFleetPreset returns a preset of the user in ctx
+func (ts *taskService) GetFleetPreset(ctx context.Context, presetID uint) (*models.FleetPreset, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] GetFleetPreset", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("presetID", presetID))
+	return ts.getOwnedFleetPreset(ctx, presetID)
+}
+
+// UpdateFleetPreset renames a preset and replaces its ships, every task using
+// it sends the new ships from its next run on
+func (ts *taskService) UpdateFleetPreset(ctx context.Context, presetID uint, update *models.FleetPreset) (*models.FleetPreset, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] UpdateFleetPreset", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("presetID", presetID))
+
+	if update.Name == "" {
+		return nil, utils.NewServiceError(http.StatusBadRequest, "Invalid Fleet Preset", errors.New("name is required"))
+	}
+	preset, serviceErr := ts.getOwnedFleetPreset(ctx, presetID)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	preset.Name = update.Name
+	preset.Ships = update.Ships
+	if err := ts.DB.Save(preset).Error; err != nil {
+		log.Error("[TaskService] UpdateFleetPreset", zap.String("traceID", traceID), zap.Uint("presetID", presetID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Update Fleet Preset Error", err)
+	}
+	return preset, nil
+}
+
+// GetFleetPresetTasks returns the tasks referencing a preset
+func (ts *taskService) GetFleetPresetTasks(ctx context.Context, presetID uint) ([]models.Task, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] GetFleetPresetTasks", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("presetID", presetID))
+
+	if _, serviceErr := ts.getOwnedFleetPreset(ctx, presetID); serviceErr != nil {
+		return nil, serviceErr
+	}
+	var tasks []models.Task
+	if err := ts.DB.Where("fleet_preset_id = ?", presetID).Order("id").Find(&tasks).Error; err != nil {
+		log.Error("[TaskService] GetFleetPresetTasks", zap.String("traceID", traceID), zap.Uint("presetID", presetID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Task Error", err)
+	}
+	return tasks, nil
+}
+
+// DeleteFleetPreset deletes a preset no task references. With force the
+// referencing tasks get the preset ships as their own fleet first.
+func (ts *taskService) DeleteFleetPreset(ctx context.Context, presetID uint, force bool) *utils.ServiceError {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] DeleteFleetPreset", zap.String("traceID", traceID), zap.Uint("userID", userID),
+		zap.Uint("presetID", presetID), zap.Bool("force", force))
+
+	preset, serviceErr := ts.getOwnedFleetPreset(ctx, presetID)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	err := ts.DB.Transaction(func(tx *gorm.DB) error {
+		var taskIDs []uint
+		if err := tx.Model(&models.Task{}).Where("fleet_preset_id = ?", presetID).Pluck("id", &taskIDs).Error; err != nil {
+			return err
+		}
+		if len(taskIDs) > 0 && !force {
+			serviceErr = utils.NewServiceError(http.StatusConflict, "Fleet Preset In Use",
+				errors.New("tasks still reference the preset, see its tasks or delete with force"))
+			return serviceErr
+		}
+		for _, taskID := range taskIDs {
+			if err := tx.Where("task_id = ?", taskID).Delete(&models.Fleet{}).Error; err != nil {
+				return err
+			}
+			fleet := preset.Ships.ToFleet()
+			fleet.TaskID = taskID
+			if err := tx.Create(&fleet).Error; err != nil {
+				return err
+			}
+		}
+		if len(taskIDs) > 0 {
+			if err := tx.Model(&models.Task{}).Where("id IN ?", taskIDs).Update("fleet_preset_id", 0).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(preset).Error
+	})
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if err != nil {
+		log.Error("[TaskService] DeleteFleetPreset", zap.String("traceID", traceID), zap.Uint("presetID", presetID), zap.Error(err))
+		return utils.NewServiceError(http.StatusInternalServerError, "Delete Fleet Preset Error", err)
+	}
+	return nil
+}
+
+// checkFleetPreset checks that the preset a task references belongs to the
+// user in ctx and may be used by the account of the task, and loads it onto
+// the task so that the handler validates the ships the task will send
+func (ts *taskService) checkFleetPreset(ctx context.Context, task *models.Task) *utils.ServiceError {
+	task.FleetPreset = nil
+	if task.FleetPresetID == 0 {
+		return nil
+	}
+	preset, serviceErr := ts.getOwnedFleetPreset(ctx, task.FleetPresetID)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if !preset.UsableBy(task.AccountID) {
+		return utils.NewServiceError(http.StatusBadRequest, "Invalid Fleet Preset",
+			errors.New("the fleet preset belongs to another account"))
+	}
+	task.FleetPreset = preset
+	return nil
+}
+
+// getOwnedFleetPreset loads a preset and checks that it belongs to the user in ctx
+func (ts *taskService) getOwnedFleetPreset(ctx context.Context, presetID uint) (*models.FleetPreset, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	var preset models.FleetPreset
+	if err := ts.DB.First(&preset, presetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewServiceError(http.StatusNotFound, "Fleet Preset Not Found", err)
+		}
+		log.Error("[TaskService] failed to get fleet preset", zap.String("traceID", traceID), zap.Uint("presetID", presetID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Fleet Preset Error", err)
+	}
+	if preset.UserID != userID {
+		log.Warn("[TaskService] Permission Denied", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("presetID", presetID))
+		return nil, utils.NewServiceError(http.StatusForbidden, "Permission Denied", nil)
+	}
+	return &preset, nil
+}
@@ -0,0 +1,93 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// fleetQueryHandler stores the ship counts reported by a query fleet request
+// as the fleet inventory of the account
+type fleetQueryHandler struct {
+	instantHandler
+}
+
+func (h *fleetQueryHandler) HandleResult(tx *gorm.DB, response *models.SingleTaskResponse) (*models.Task, error) {
+	if response.Status != models.TASK_RESULT_SUCCESS {
+		return nil, nil // the inventory keeps its last known counts
+	}
+	var result models.FleetQueryResult
+	if err := json.Unmarshal(response.Payload(), &result); err != nil {
+		log.Error("[fleetQueryHandler::HandleResult] failed to unmarshal fleet",
+			zap.String("uuid", response.UUID),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to unmarshal fleet: %w", err)
+	}
+	var taskLog models.TaskLog
+	if err := tx.Select("account_id").Where("uuid = ?", response.UUID).First(&taskLog).Error; err != nil {
+		return nil, fmt.Errorf("failed to load task log: %w", err)
+	}
+	if err := saveFleetInventory(tx, taskLog.AccountID, response.UUID, result.Planets); err != nil {
+		log.Error("[fleetQueryHandler::HandleResult] failed to save fleet inventory",
+			zap.String("uuid", response.UUID),
+			zap.Uint("account_id", taskLog.AccountID),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to save fleet inventory: %w", err)
+	}
+	log.Info("[fleetQueryHandler::HandleResult] fleet inventory updated",
+		zap.String("uuid", response.UUID),
+		zap.Uint("account_id", taskLog.AccountID),
+		zap.Int("planets", len(result.Planets)))
+	return nil, nil
+}
+
+// saveFleetInventory replaces the inventory of an account with the planets of
+// a query and gives its tasks waiting for ships another chance, the scheduler
+// picks them up on its next resync
+func saveFleetInventory(tx *gorm.DB, accountID uint, uuid string, planets []models.PlanetFleet) error {
+	planetIDs := make([]uint, 0, len(planets))
+	for _, planet := range planets {
+		inventory := models.FleetInventory{
+			AccountID: accountID,
+			PlanetID:  planet.PlanetID,
+			Position:  planet.Position,
+			Ships:     planet.Fleet,
+			UUID:      uuid,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "account_id"}, {Name: "planet_id"}},
+			UpdateAll: true,
+		}).Create(&inventory).Error; err != nil {
+			return err
+		}
+		planetIDs = append(planetIDs, planet.PlanetID)
+	}
+	// Planets missing from the report are lost
+	stale := tx.Where("account_id = ?", accountID)
+	if len(planetIDs) > 0 {
+		stale = stale.Where("planet_id NOT IN ?", planetIDs)
+	}
+	if err := stale.Delete(&models.FleetInventory{}).Error; err != nil {
+		return err
+	}
+
+	var waiting []uint
+	if err := tx.Model(&models.Task{}).Where("account_id = ? AND status = ?", accountID, models.TASK_STATUS_INSUFFICIENT_FLEET).
+		Pluck("id", &waiting).Error; err != nil {
+		return err
+	}
+	for _, taskID := range waiting {
+		task, err := lockTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		if err := transitionTask(tx, task, models.TASK_STATUS_READY, "fleet inventory updated", uuid, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
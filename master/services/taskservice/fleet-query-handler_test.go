@@ -0,0 +1,56 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"testing"
+)
+
+func Test_saveFleetInventory(t *testing.T) {
+	db := newTestDB(t, &models.FleetInventory{})
+	for _, planet := range []models.FleetInventory{
+		{AccountID: 5, PlanetID: 7, Position: "1:2:3:0", Ships: models.FleetDTO{Cargo: 1}},
+		{AccountID: 5, PlanetID: 9, Position: "1:2:9:0"},
+		{AccountID: 6, PlanetID: 9, Position: "4:4:4:0"},
+	} {
+		if err := db.Create(&planet).Error; err != nil {
+			t.Fatalf("create inventory: %v", err)
+		}
+	}
+	waiting := &models.Task{Name: "waiting", AccountID: 5, Status: models.TASK_STATUS_INSUFFICIENT_FLEET}
+	other := &models.Task{Name: "other account", AccountID: 6, Status: models.TASK_STATUS_INSUFFICIENT_FLEET}
+	for _, task := range []*models.Task{waiting, other} {
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	if err := saveFleetInventory(db, 5, "query-1", []models.PlanetFleet{
+		{PlanetID: 7, Position: "1:2:3:0", Fleet: models.FleetDTO{Cargo: 20}},
+		{PlanetID: 8, Position: "1:2:8:0", Fleet: models.FleetDTO{LightFighter: 3}},
+	}); err != nil {
+		t.Fatalf("saveFleetInventory() error = %v", err)
+	}
+
+	inventory, err := loadFleetInventory(db, 5)
+	if err != nil {
+		t.Fatalf("loadFleetInventory() error = %v", err)
+	}
+	if len(inventory) != 2 || inventory[7].Ships.Cargo != 20 || inventory[8].Ships.LightFighter != 3 || inventory[7].UUID != "query-1" {
+		t.Errorf("inventory = %+v, want planets 7 and 8 of the query", inventory)
+	}
+	if other, err := loadFleetInventory(db, 6); err != nil || len(other) != 1 {
+		t.Errorf("inventory of another account = %+v, %v, want it untouched", other, err)
+	}
+	for _, want := range []struct {
+		task   *models.Task
+		status string
+	}{{waiting, models.TASK_STATUS_READY}, {other, models.TASK_STATUS_INSUFFICIENT_FLEET}} {
+		var got models.Task
+		if err := db.First(&got, want.task.ID).Error; err != nil {
+			t.Fatalf("find task: %v", err)
+		}
+		if got.Status != want.status {
+			t.Errorf("task %q status = %s, want %s", got.Name, got.Status, want.status)
+		}
+	}
+}
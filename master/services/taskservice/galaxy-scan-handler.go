@@ -0,0 +1,99 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/config"
+	"GalaxyEmpireWeb/models"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// galaxyScanHandler handles galaxy scans, their result replaces the scanned
+// systems in the universe catalog of the server of the account
+type galaxyScanHandler struct {
+	instantHandler
+}
+
+func (h *galaxyScanHandler) Timeout() time.Duration {
+	return config.SCAN_TASK_TIMEOUT
+}
+
+func (h *galaxyScanHandler) HandleResult(tx *gorm.DB, response *models.SingleTaskResponse) (*models.Task, error) {
+	if response.Status != models.TASK_RESULT_SUCCESS {
+		return nil, nil // the catalog keeps its last known planets
+	}
+	var result models.GalaxyScanResult
+	if err := json.Unmarshal(response.Payload(), &result); err != nil {
+		log.Error("[galaxyScanHandler::HandleResult] failed to unmarshal scan",
+			zap.String("uuid", response.UUID),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to unmarshal scan: %w", err)
+	}
+	if err := result.ScanRange.Validate(config.SCAN_MAX_SYSTEMS); err != nil {
+		return nil, fmt.Errorf("%w: scan range: %v", errInvalidResult, err)
+	}
+	run, err := loadRun(tx, response.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task log: %w", err)
+	}
+	var account models.Account
+	if err := tx.Unscoped().Select("server").First(&account, run.AccountID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load account %d: %w", run.AccountID, err)
+	}
+	if err := saveUniverse(tx, account.Server, response.UUID, &result); err != nil {
+		log.Error("[galaxyScanHandler::HandleResult] failed to save universe",
+			zap.String("uuid", response.UUID),
+			zap.String("server", account.Server),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to save universe: %w", err)
+	}
+	log.Info("[galaxyScanHandler::HandleResult] universe updated",
+		zap.String("uuid", response.UUID),
+		zap.String("server", account.Server),
+		zap.Int("galaxy", result.Galaxy),
+		zap.Int("system_from", result.SystemFrom),
+		zap.Int("system_to", result.SystemTo),
+		zap.Int("planets", len(result.Planets)))
+	return nil, nil
+}
+
+// saveUniverse upserts the planets of a scan into the catalog of a server and
+// drops the positions of the scanned systems that are empty now
+func saveUniverse(tx *gorm.DB, server string, uuid string, result *models.GalaxyScanResult) error {
+	for _, scanned := range result.Planets {
+		if scanned.System < result.SystemFrom || scanned.System > result.SystemTo {
+			continue // outside of what was asked, not trusted
+		}
+		planet := models.UniversePlanet{
+			Server: server,
+			Star: models.Star{
+				Galaxy:   result.Galaxy,
+				Solar:    scanned.System,
+				Location: scanned.Position,
+				StarID:   scanned.PlanetID,
+			},
+			PlanetName: scanned.PlanetName,
+			PlayerID:   scanned.PlayerID,
+			PlayerName: scanned.PlayerName,
+			Alliance:   scanned.Alliance,
+			HasMoon:    scanned.HasMoon,
+			MoonID:     scanned.MoonID,
+			Activity:   scanned.Activity,
+			UUID:       uuid,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "server"}, {Name: "galaxy"}, {Name: "solar"}, {Name: "location"}},
+			UpdateAll: true,
+		}).Create(&planet).Error; err != nil {
+			return err
+		}
+	}
+	// Positions of the range the scan did not report were left or destroyed
+	return tx.Where("server = ? AND galaxy = ? AND solar BETWEEN ? AND ? AND uuid <> ?",
+		server, result.Galaxy, result.SystemFrom, result.SystemTo, uuid).
+		Delete(&models.UniversePlanet{}).Error
+}
@@ -0,0 +1,55 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"reflect"
+	"testing"
+)
+
+func Test_saveUniverse(t *testing.T) {
+	db := newTestDB(t, &models.UniversePlanet{})
+	for _, planet := range []models.UniversePlanet{
+		{Server: "s1", Star: models.Star{Galaxy: 1, Solar: 10, Location: 5}, PlanetName: "old", UUID: "scan-1"},
+		{Server: "s1", Star: models.Star{Galaxy: 1, Solar: 11, Location: 3}, PlanetName: "left", UUID: "scan-1"},
+		{Server: "s1", Star: models.Star{Galaxy: 1, Solar: 20, Location: 1}, PlanetName: "not scanned", UUID: "scan-1"},
+		{Server: "s2", Star: models.Star{Galaxy: 1, Solar: 11, Location: 3}, PlanetName: "other server", UUID: "scan-1"},
+	} {
+		if err := db.Create(&planet).Error; err != nil {
+			t.Fatalf("create planet: %v", err)
+		}
+	}
+
+	result := &models.GalaxyScanResult{
+		ScanRange: models.ScanRange{Galaxy: 1, SystemFrom: 10, SystemTo: 12},
+		Planets: []models.ScannedPlanet{
+			{System: 10, Position: 5, PlanetID: 50, PlanetName: "renamed", PlayerID: 3},
+			{System: 12, Position: 7, PlanetID: 70, PlanetName: "new"},
+			{System: 30, Position: 1, PlanetName: "outside of the range"},
+		},
+	}
+	if err := saveUniverse(db, "s1", "scan-2", result); err != nil {
+		t.Fatalf("saveUniverse() error = %v", err)
+	}
+
+	names := func(server string) []string {
+		var names []string
+		if err := db.Model(&models.UniversePlanet{}).Where("server = ?", server).
+			Order("galaxy, solar, location").Pluck("planet_name", &names).Error; err != nil {
+			t.Fatalf("find planets: %v", err)
+		}
+		return names
+	}
+	if got, want := names("s1"), []string{"renamed", "new", "not scanned"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("catalog of s1 = %v, want %v", got, want)
+	}
+	if got, want := names("s2"), []string{"other server"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("catalog of s2 = %v, want %v", got, want)
+	}
+	var renamed models.UniversePlanet
+	if err := db.Where("server = ? AND solar = ? AND location = ?", "s1", 10, 5).First(&renamed).Error; err != nil {
+		t.Fatalf("find planet: %v", err)
+	}
+	if renamed.Star.StarID != 50 || renamed.PlayerID != 3 || renamed.UUID != "scan-2" {
+		t.Errorf("updated planet = %+v, want the scan", renamed)
+	}
+}
@@ -0,0 +1,36 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/config"
+	"GalaxyEmpireWeb/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// instantHandler handles one-shot requests such as login checks and planet id
+// queries. They are never stored as tasks, the caller reads the task log.
+type instantHandler struct {
+	name string
+}
+
+func (h *instantHandler) Validate(task *models.Task) error {
+	return fmt.Errorf("%s tasks can not be scheduled", h.name)
+}
+
+func (h *instantHandler) BuildRequest(task *models.Task, account *models.Account) (*models.SingleTaskRequest, error) {
+	return nil, fmt.Errorf("%s tasks can not be scheduled", h.name)
+}
+
+func (h *instantHandler) RetryPolicy() models.RetryPolicy {
+	return models.RetryPolicy{} // the caller decides whether to ask again
+}
+
+func (h *instantHandler) Timeout() time.Duration {
+	return config.INSTANT_TASK_TIMEOUT
+}
+
+func (h *instantHandler) HandleResult(tx *gorm.DB, response *models.SingleTaskResponse) (*models.Task, error) {
+	return nil, nil
+}
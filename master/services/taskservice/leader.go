@@ -0,0 +1,99 @@
+package taskservice
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// renewLease extends the lease only while it is still held by this instance
+var renewLease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// leaderElector holds a Redis lease so that only one master replica runs the
+// task generator. The lease expires when its holder dies and another replica
+// takes it over on its next attempt.
+type leaderElector struct {
+	rdb    *redis.Client
+	key    string
+	id     string
+	ttl    time.Duration
+	leader atomic.Bool
+}
+
+func newLeaderElector(rdb *redis.Client, key string, ttl time.Duration) *leaderElector {
+	hostname, _ := os.Hostname()
+	return &leaderElector{
+		rdb: rdb,
+		key: key,
+		id:  fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()),
+		ttl: ttl,
+	}
+}
+
+// IsLeader reports whether this instance held the lease at its last attempt
+func (l *leaderElector) IsLeader() bool {
+	return l.leader.Load()
+}
+
+// Run acquires or renews the lease every third of its ttl until ctx is done
+func (l *leaderElector) Run(ctx context.Context) {
+	log.Info("[TaskService::Leader] start leader election", zap.String("id", l.id), zap.String("key", l.key))
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		l.tryLead(ctx)
+		select {
+		case <-ctx.Done():
+			l.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *leaderElector) tryLead(ctx context.Context) {
+	var leading bool
+	var err error
+	if l.IsLeader() {
+		var renewed int64
+		renewed, err = renewLease.Run(ctx, l.rdb, []string{l.key}, l.id, l.ttl.Milliseconds()).Int64()
+		leading = renewed == 1
+	} else {
+		leading, err = l.rdb.SetNX(ctx, l.key, l.id, l.ttl).Result()
+	}
+	if err != nil {
+		// Without Redis we can not know whether another replica leads, stand down
+		log.Error("[TaskService::Leader] lease request failed", zap.String("id", l.id), zap.Error(err))
+		leading = false
+	}
+	if leading != l.leader.Swap(leading) {
+		if leading {
+			log.Info("[TaskService::Leader] became task generator leader", zap.String("id", l.id))
+		} else {
+			log.Warn("[TaskService::Leader] lost task generator leadership", zap.String("id", l.id))
+		}
+	}
+}
+
+// resign releases the lease so another replica does not wait for it to expire
+func (l *leaderElector) resign() {
+	if !l.leader.Swap(false) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := renewLease.Run(ctx, l.rdb, []string{l.key}, l.id, 1).Err(); err != nil {
+		log.Error("[TaskService::Leader] failed to release lease", zap.String("id", l.id), zap.Error(err))
+	}
+}
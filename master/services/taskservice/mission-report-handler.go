@@ -0,0 +1,127 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// attackHandler handles attack missions and stores their battle report
+type attackHandler struct {
+	fleetHandler
+}
+
+func (h *attackHandler) HandleResult(tx *gorm.DB, response *models.SingleTaskResponse) (*models.Task, error) {
+	task, err := h.fleetHandler.HandleResult(tx, response)
+	if err != nil || response.Status != models.TASK_RESULT_SUCCESS {
+		return task, err
+	}
+	// Older nodes send the report along with the result
+	if err := h.HandleReport(tx, response); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (h *attackHandler) HandleReport(tx *gorm.DB, response *models.SingleTaskResponse) error {
+	var payload models.BattlePayload
+	if !decodePayload(response, &payload) {
+		return nil
+	}
+	run, err := loadRun(tx, response.UUID)
+	if err != nil {
+		return fmt.Errorf("failed to record battle report: %w", err)
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.BattleReport{
+		UUID:           response.UUID,
+		TaskID:         response.TaskID,
+		AccountID:      run.AccountID,
+		TargetID:       run.TargetID,
+		Outcome:        payload.Outcome,
+		Rounds:         payload.Rounds,
+		Loot:           payload.Loot,
+		Debris:         payload.Debris,
+		AttackerLosses: payload.AttackerLosses,
+		DefenderLosses: payload.DefenderLosses,
+		BackTimestamp:  response.BackTimestamp,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record battle report: %w", err)
+	}
+	return nil
+}
+
+// exploreHandler handles expeditions and stores their findings
+type exploreHandler struct {
+	fleetHandler
+}
+
+func (h *exploreHandler) HandleResult(tx *gorm.DB, response *models.SingleTaskResponse) (*models.Task, error) {
+	task, err := h.fleetHandler.HandleResult(tx, response)
+	if err != nil || response.Status != models.TASK_RESULT_SUCCESS {
+		return task, err
+	}
+	// Older nodes send the report along with the result
+	if err := h.HandleReport(tx, response); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (h *exploreHandler) HandleReport(tx *gorm.DB, response *models.SingleTaskResponse) error {
+	var payload models.ExpeditionPayload
+	if !decodePayload(response, &payload) {
+		return nil
+	}
+	run, err := loadRun(tx, response.UUID)
+	if err != nil {
+		return fmt.Errorf("failed to record expedition report: %w", err)
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ExpeditionReport{
+		UUID:          response.UUID,
+		TaskID:        response.TaskID,
+		AccountID:     run.AccountID,
+		TargetID:      run.TargetID,
+		Outcome:       payload.Outcome,
+		Resources:     payload.Resources,
+		DarkMatter:    payload.DarkMatter,
+		ShipsFound:    payload.ShipsFound,
+		ShipsLost:     payload.ShipsLost,
+		Message:       payload.Message,
+		BackTimestamp: response.BackTimestamp,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record expedition report: %w", err)
+	}
+	return nil
+}
+
+// decodePayload decodes the payload of a successful result into v. A result
+// without payload or with one that does not parse is still a successful run,
+// it only has no report.
+func decodePayload(response *models.SingleTaskResponse, v interface{}) bool {
+	payload := response.Payload()
+	if len(payload) == 0 {
+		return false
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		log.Warn("[TaskService] result payload does not parse, no report stored",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", response.TaskID),
+			zap.Int("task_type", response.TaskType),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// loadRun loads the account and target of the run a result belongs to
+func loadRun(tx *gorm.DB, uuid string) (*models.TaskLog, error) {
+	var taskLog models.TaskLog
+	if err := tx.Select("account_id", "target_id").Where("uuid = ?", uuid).First(&taskLog).Error; err != nil {
+		return nil, err
+	}
+	return &taskLog, nil
+}
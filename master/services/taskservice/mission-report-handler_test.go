@@ -0,0 +1,145 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory database with the tables of tasks and their
+// runs, plus tables
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1) // every connection has its own memory database
+	t.Cleanup(func() { sqlDB.Close() })
+	tables = append([]interface{}{&models.Task{}, &models.Target{}, &models.Fleet{}, &models.TaskLog{}, &models.TaskTransition{}}, tables...)
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// newResultDB opens an in-memory database holding one dispatched task of
+// taskType with one target and the running log of run "run-1"
+func newResultDB(t *testing.T, taskType int) (*gorm.DB, *models.Task, *models.TaskLog) {
+	t.Helper()
+	db := newTestDB(t, &models.BattleReport{}, &models.ExpeditionReport{})
+	task := &models.Task{Name: "farm", AccountID: 5, TaskType: taskType, Enabled: true,
+		Status: models.TASK_STATUS_DISPATCHED, Targets: []models.Target{{Galaxy: 1, System: 2, Planet: 3}}}
+	if err := db.Create(task).Error; err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	run := &models.TaskLog{TaskID: task.ID, AccountID: 5, TaskType: taskType, UUID: "run-1",
+		Status: models.TASK_RESULT_RUNNING, TargetID: task.Targets[0].ID}
+	if err := db.Create(run).Error; err != nil {
+		t.Fatalf("create task log: %v", err)
+	}
+	return db, task, run
+}
+
+func handleResult(t *testing.T, db *gorm.DB, response *models.SingleTaskResponse) *models.Task {
+	t.Helper()
+	handler, err := getHandler(response.TaskType)
+	if err != nil {
+		t.Fatalf("getHandler() error = %v", err)
+	}
+	var task *models.Task
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		task, err = handler.HandleResult(tx, response)
+		return err
+	}); err != nil {
+		t.Fatalf("HandleResult() error = %v", err)
+	}
+	return task
+}
+
+func TestAttackHandler_HandleResult_storesBattleReport(t *testing.T) {
+	db, task, run := newResultDB(t, models.TASKTYPE_ATTACK)
+	payload := models.BattlePayload{
+		Outcome:        models.BATTLE_OUTCOME_WIN,
+		Rounds:         2,
+		Loot:           models.ResourceAmounts{Metal: 1200, Crystal: 800},
+		Debris:         models.ResourceAmounts{Metal: 300},
+		AttackerLosses: map[string]int64{"lf": 3},
+		DefenderLosses: map[string]int64{"cargo": 1, "401": 10},
+	}
+	data, _ := json.Marshal(payload)
+
+	got := handleResult(t, db, &models.SingleTaskResponse{TaskID: task.ID, UUID: run.UUID, TaskType: models.TASKTYPE_ATTACK,
+		Status: models.TASK_RESULT_SUCCESS, BackTimestamp: 1700000000, Data: data})
+	if got.Status != models.TASK_STATUS_RETURNED {
+		t.Errorf("task status = %s, want %s", got.Status, models.TASK_STATUS_RETURNED)
+	}
+
+	var reports []models.BattleReport
+	if err := db.Find(&reports).Error; err != nil {
+		t.Fatalf("find battle reports: %v", err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("battle reports = %d, want 1", len(reports))
+	}
+	report := reports[0]
+	if report.UUID != run.UUID || report.TaskID != task.ID || report.AccountID != 5 || report.TargetID != run.TargetID ||
+		report.BackTimestamp != 1700000000 {
+		t.Errorf("report run = %+v, want the run of the result", report)
+	}
+	if report.Outcome != payload.Outcome || report.Rounds != payload.Rounds ||
+		report.Loot != payload.Loot || report.Debris != payload.Debris ||
+		!reflect.DeepEqual(report.AttackerLosses, payload.AttackerLosses) ||
+		!reflect.DeepEqual(report.DefenderLosses, payload.DefenderLosses) {
+		t.Errorf("report = %+v, want the payload %+v", report, payload)
+	}
+}
+
+func TestExploreHandler_HandleResult_storesExpeditionReport(t *testing.T) {
+	db, task, run := newResultDB(t, models.TASKTYPE_EXPLORE)
+	payload := models.ExpeditionPayload{
+		Outcome:    models.EXPEDITION_OUTCOME_SHIPS,
+		ShipsFound: map[string]int64{"cargo": 4},
+		Message:    "Your fleet found abandoned ships.",
+	}
+	data, _ := json.Marshal(payload)
+
+	// Older nodes put the payload in msg
+	handleResult(t, db, &models.SingleTaskResponse{TaskID: task.ID, UUID: run.UUID, TaskType: models.TASKTYPE_EXPLORE,
+		Status: models.TASK_RESULT_SUCCESS, BackTimestamp: 1700000000, Msg: string(data)})
+
+	var report models.ExpeditionReport
+	if err := db.Where("uuid = ?", run.UUID).First(&report).Error; err != nil {
+		t.Fatalf("find expedition report: %v", err)
+	}
+	if report.Outcome != payload.Outcome || report.Message != payload.Message ||
+		!reflect.DeepEqual(report.ShipsFound, payload.ShipsFound) || report.TargetID != run.TargetID {
+		t.Errorf("report = %+v, want the payload %+v", report, payload)
+	}
+}
+
+func TestAttackHandler_HandleResult_withoutPayload(t *testing.T) {
+	db, task, run := newResultDB(t, models.TASKTYPE_ATTACK)
+
+	got := handleResult(t, db, &models.SingleTaskResponse{TaskID: task.ID, UUID: run.UUID, TaskType: models.TASKTYPE_ATTACK,
+		Status: models.TASK_RESULT_SUCCESS, BackTimestamp: 1700000000})
+	if got.Status != models.TASK_STATUS_RETURNED {
+		t.Errorf("task status = %s, want %s", got.Status, models.TASK_STATUS_RETURNED)
+	}
+	var count int64
+	if err := db.Model(&models.BattleReport{}).Count(&count).Error; err != nil {
+		t.Fatalf("count battle reports: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("battle reports = %d, want none without payload", count)
+	}
+}
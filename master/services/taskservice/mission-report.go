@@ -0,0 +1,74 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ListBattleReports returns a page of the battle reports of an attack task and
+// their number. Status and task type of the filter do not apply.
+func (ts *taskService) ListBattleReports(ctx context.Context, taskID uint, filter *models.TaskLogFilter) ([]models.BattleReport, int64, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ListBattleReports", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
+
+	if _, serviceErr := ts.getAllowedTask(ctx, taskID, "read"); serviceErr != nil {
+		return nil, 0, serviceErr
+	}
+	var reports []models.BattleReport
+	total, err := listTaskReports(ts.DB.Model(&models.BattleReport{}), taskID, filter, &reports)
+	if err != nil {
+		log.Error("[TaskService] ListBattleReports", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Get Battle Report Error", err)
+	}
+	return reports, total, nil
+}
+
+// ListExpeditionReports returns a page of the expedition reports of an explore
+// task and their number. Status and task type of the filter do not apply.
+func (ts *taskService) ListExpeditionReports(ctx context.Context, taskID uint, filter *models.TaskLogFilter) ([]models.ExpeditionReport, int64, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ListExpeditionReports", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
+
+	if _, serviceErr := ts.getAllowedTask(ctx, taskID, "read"); serviceErr != nil {
+		return nil, 0, serviceErr
+	}
+	var reports []models.ExpeditionReport
+	total, err := listTaskReports(ts.DB.Model(&models.ExpeditionReport{}), taskID, filter, &reports)
+	if err != nil {
+		log.Error("[TaskService] ListExpeditionReports", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Get Expedition Report Error", err)
+	}
+	return reports, total, nil
+}
+
+// listTaskReports finds into dest a page of the reports of a task in the
+// table of query and returns the number of reports matching the filter
+func listTaskReports(query *gorm.DB, taskID uint, filter *models.TaskLogFilter, dest interface{}) (int64, error) {
+	query = query.Where("task_id = ?", taskID)
+	if filter.Since != 0 {
+		query = query.Where("created_at >= ?", time.Unix(filter.Since, 0))
+	}
+	if filter.Until != 0 {
+		query = query.Where("created_at < ?", time.Unix(filter.Until, 0))
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, err
+	}
+	order := "created_at DESC, id DESC"
+	if filter.Asc {
+		order = "created_at, id"
+	}
+	err := query.Order(order).
+		Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(dest).Error
+	return total, err
+}
@@ -0,0 +1,109 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/config"
+	"GalaxyEmpireWeb/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const reaperBatchSize = 100
+
+// ReapLoop times out the runs whose task log passed its deadline, on the leader only
+func (ts *taskService) ReapLoop() {
+	log.Info("[TaskService::ReapLoop] start task log reaper loop")
+	for {
+		if ts.leader == nil || ts.leader.IsLeader() {
+			if err := ts.reapOverdueLogs(time.Now()); err != nil {
+				log.Error("[TaskService::ReapLoop] failed to reap overdue task logs", zap.Error(err))
+			}
+		}
+		time.Sleep(config.TASK_REAPER_INTERVAL)
+	}
+}
+
+func (ts *taskService) reapOverdueLogs(now time.Time) error {
+	var taskLogs []models.TaskLog
+	if err := ts.DB.Where("status = ?", models.TASK_RESULT_RUNNING).
+		Where("(deadline > 0 AND deadline < ?) OR (deadline = 0 AND created_at < ?)",
+			now.Unix(), now.Add(-config.FLEET_TASK_TIMEOUT)).
+		Order("id").Limit(reaperBatchSize).
+		Find(&taskLogs).Error; err != nil {
+		return err
+	}
+	for i := range taskLogs {
+		if err := ts.timeoutLog(&taskLogs[i]); err != nil {
+			log.Error("[TaskService::ReapLoop] failed to time out task log",
+				zap.String("uuid", taskLogs[i].UUID),
+				zap.Uint("task_id", taskLogs[i].TaskID),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// timeoutLog marks a run as timed out and hands it to its handler like a failed result
+func (ts *taskService) timeoutLog(taskLog *models.TaskLog) error {
+	errMsg := "no result before the deadline"
+	err := ts.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TaskLog{}).
+			Where("id = ? AND status = ?", taskLog.ID, models.TASK_RESULT_RUNNING).
+			Updates(map[string]interface{}{
+				"status":  models.TASK_RESULT_TIMEOUT,
+				"err_msg": errMsg,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error // the result came in meanwhile
+		}
+		log.Warn("[TaskService::ReapLoop] task log timed out",
+			zap.String("uuid", taskLog.UUID),
+			zap.Uint("task_id", taskLog.TaskID),
+			zap.Time("deadline", time.Unix(taskLog.Deadline, 0)))
+		if taskLog.TaskID == 0 {
+			return nil // instant tasks are read from the log only
+		}
+
+		taskType := taskLog.TaskType
+		if taskType == 0 { // logs written before the task type was recorded
+			var task models.Task
+			if err := tx.Select("task_type").First(&task, taskLog.TaskID).Error; err != nil {
+				return fmt.Errorf("failed to load task type: %w", err)
+			}
+			taskType = task.TaskType
+		}
+		handler, err := getHandler(taskType)
+		if err != nil {
+			return err
+		}
+		_, err = handler.HandleResult(tx, &models.SingleTaskResponse{
+			TaskID:   taskLog.TaskID,
+			TaskType: taskType,
+			UUID:     taskLog.UUID,
+			Status:   models.TASK_RESULT_TIMEOUT,
+			ErrMsg:   errMsg,
+		})
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The task was deleted meanwhile, close the log alone or it is picked on every pass
+		log.Warn("[TaskService::ReapLoop] task of the timed out log is gone",
+			zap.String("uuid", taskLog.UUID),
+			zap.Uint("task_id", taskLog.TaskID))
+		err = ts.DB.Model(&models.TaskLog{}).
+			Where("id = ? AND status = ?", taskLog.ID, models.TASK_RESULT_RUNNING).
+			Updates(map[string]interface{}{
+				"status":  models.TASK_RESULT_TIMEOUT,
+				"err_msg": errMsg,
+			}).Error
+	}
+	if err != nil {
+		return err
+	}
+	ts.reindexTask(context.Background(), taskLog.TaskID)
+	return nil
+}
@@ -0,0 +1,91 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func logStatus(t *testing.T, db *gorm.DB, uuid string) int {
+	t.Helper()
+	var taskLog models.TaskLog
+	if err := db.Where("uuid = ?", uuid).First(&taskLog).Error; err != nil {
+		t.Fatalf("find task log: %v", err)
+	}
+	return taskLog.Status
+}
+
+func Test_taskService_reapOverdueLogs(t *testing.T) {
+	now := time.Now()
+	db, task, run := newResultDB(t, models.TASKTYPE_ATTACK)
+	if err := db.Model(run).Update("deadline", now.Add(-time.Minute).Unix()).Error; err != nil {
+		t.Fatalf("set deadline: %v", err)
+	}
+	pending := &models.TaskLog{TaskID: task.ID, AccountID: 5, TaskType: models.TASKTYPE_ATTACK, UUID: "run-2",
+		Status: models.TASK_RESULT_RUNNING, Deadline: now.Add(time.Hour).Unix()}
+	if err := db.Create(pending).Error; err != nil {
+		t.Fatalf("create task log: %v", err)
+	}
+	ts := &taskService{DB: db}
+
+	if err := ts.reapOverdueLogs(now); err != nil {
+		t.Fatalf("reapOverdueLogs() error = %v", err)
+	}
+	if status := logStatus(t, db, run.UUID); status != models.TASK_RESULT_TIMEOUT {
+		t.Errorf("overdue log status = %d, want %d", status, models.TASK_RESULT_TIMEOUT)
+	}
+	if status := logStatus(t, db, pending.UUID); status != models.TASK_RESULT_RUNNING {
+		t.Errorf("pending log status = %d, want %d", status, models.TASK_RESULT_RUNNING)
+	}
+	var got models.Task
+	if err := db.First(&got, task.ID).Error; err != nil {
+		t.Fatalf("find task: %v", err)
+	}
+	if got.Status != models.TASK_STATUS_FAILED || got.ConsecutiveFailures != 1 {
+		t.Errorf("task = %s with %d failures, want %s with 1", got.Status, got.ConsecutiveFailures, models.TASK_STATUS_FAILED)
+	}
+}
+
+func Test_taskService_reapOverdueLogs_deletedTask(t *testing.T) {
+	now := time.Now()
+	db, task, run := newResultDB(t, models.TASKTYPE_ATTACK)
+	if err := db.Model(run).Update("deadline", now.Add(-time.Minute).Unix()).Error; err != nil {
+		t.Fatalf("set deadline: %v", err)
+	}
+	if err := db.Delete(task).Error; err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	ts := &taskService{DB: db}
+
+	if err := ts.timeoutLog(run); err != nil {
+		t.Fatalf("timeoutLog() error = %v", err)
+	}
+	if status := logStatus(t, db, run.UUID); status != models.TASK_RESULT_TIMEOUT {
+		t.Errorf("log status = %d, want %d", status, models.TASK_RESULT_TIMEOUT)
+	}
+}
+
+func Test_taskService_abandonDispatch(t *testing.T) {
+	db, task, run := newResultDB(t, models.TASKTYPE_ATTACK)
+	if err := db.Model(task).Update("status", models.TASK_STATUS_QUEUED).Error; err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	ts := &taskService{DB: db}
+
+	if err := ts.abandonDispatch(task.ID, run.UUID, errors.New("channel closed")); err != nil {
+		t.Fatalf("abandonDispatch() error = %v", err)
+	}
+	if status := logStatus(t, db, run.UUID); status != models.TASK_RESULT_FAILED {
+		t.Errorf("log status = %d, want %d", status, models.TASK_RESULT_FAILED)
+	}
+	var got models.Task
+	if err := db.First(&got, task.ID).Error; err != nil {
+		t.Fatalf("find task: %v", err)
+	}
+	if got.Status != models.TASK_STATUS_READY {
+		t.Errorf("task status = %s, want %s", got.Status, models.TASK_STATUS_READY)
+	}
+}
@@ -3,12 +3,15 @@ package taskservice
 import (
 	"GalaxyEmpireWeb/config"
 	"GalaxyEmpireWeb/models"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func (ts *taskService) HandleSingleResult(response *models.SingleTaskResponse) (*models.Task, error) {
@@ -19,11 +22,24 @@ func (ts *taskService) HandleSingleResult(response *models.SingleTaskResponse) (
 	log.Info("[TaskService::HandleSingleResult] handling single result",
 		zap.String("uuid", response.UUID),
 		zap.Uint("task_id", response.TaskID),
+		zap.Int("task_type", response.TaskType),
 		zap.Int("status", response.Status),
 		zap.Int("BackTimestamp", int(response.BackTimestamp)))
 
-	var task models.Task
-	task.ID = response.TaskID
+	if response.Status == models.TASK_RESULT_RUNNING {
+		return ts.handleRunningResult(response)
+	}
+	if response.Status == models.TASK_RESULT_REPORT {
+		return nil, ts.handleReportResult(response)
+	}
+
+	handler, err := getHandler(response.TaskType)
+	if err != nil {
+		log.Error("[TaskService::HandleSingleResult] unknown task type",
+			zap.String("uuid", response.UUID),
+			zap.Int("task_type", response.TaskType))
+		return nil, err
+	}
 
 	tx := ts.DB.Begin()
 	if err := tx.Error; err != nil {
@@ -32,138 +48,213 @@ func (ts *taskService) HandleSingleResult(response *models.SingleTaskResponse) (
 			zap.String("uuid", response.UUID))
 		return nil, err
 	}
-	// Handle login task
-	if response.TaskType == models.TASKTYPE_LOGIN {
-		succeed := response.Status == models.TASK_RESULT_SUCCESS
-		if err := tx.Model(&models.TaskLog{}).
-			Where("uuid = ?", response.UUID).
-			Update("status", succeed).Error; err != nil {
-			tx.Rollback()
-			log.Error("[TaskService::HandleSingleResult] failed to update login task log",
-				zap.String("uuid", response.UUID),
-				zap.Error(err))
-			return nil, fmt.Errorf("failed to update login task log: %w", err)
-		}
-		return nil, tx.Commit().Error
-	}
-	if response.TaskType == models.TASKTYPE_QUERY_PLANET_ID {
-		// Handle query planet ID task
-		succeed := response.Status == models.TASK_RESULT_SUCCESS
-		if err := tx.Model(&models.TaskLog{}).
-			Where("uuid = ?", response.UUID).
-			Update("status", succeed).
-			Update("msg", response.Msg).
-			Update("err_msg", response.ErrMsg).Error; err != nil {
-			tx.Rollback()
-			log.Error("[TaskService::HandleSingleResult] failed to update query planet ID task log",
-				zap.String("uuid", response.UUID),
-				zap.Error(err))
-			return nil, fmt.Errorf("failed to update query planet ID task log: %w", err)
-		}
-		if err := tx.Commit().Error; err != nil {
-			tx.Rollback()
-			log.Error("[TaskService::HandleSingleResult] failed to commit transaction",
-				zap.String("uuid", response.UUID),
-				zap.Error(err))
-			return nil, fmt.Errorf("failed to commit transaction: %w", err)
-		}
-	}
 
-	// Remaining tasks Attack and Explore
-	if response.Status != models.TASK_RESULT_SUCCESS {
-		// Update task log to failed status
-		if err := tx.Model(&models.TaskLog{}).
-			Where("uuid = ?", response.UUID).
-			Update("status", models.TASK_RESULT_FAILED).Error; err != nil {
-			tx.Rollback()
-			log.Error("[TaskService::HandleSingleResult] failed to update failed task log",
-				zap.String("uuid", response.UUID),
-				zap.Uint("task_id", task.ID),
-				zap.Error(err))
-			return nil, err
-		}
-
-		// 即使任务失败也要更新任务状态和下次执行时间
-		if err := tx.Model(&task).Updates(map[string]interface{}{
-			"status":     models.TaskStatusMap[models.TASK_STATUS_READY],
-			"next_start": time.Now().Unix() + config.FAILED_TASK_DELAY,
-		}).Error; err != nil {
+	// Results are applied once, a retried or late message finds the log terminal
+	taskLog, err := lockTaskLog(tx, response)
+	if err != nil {
+		tx.Rollback()
+		log.Error("[TaskService::HandleSingleResult] rejected result",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", response.TaskID),
+			zap.Error(err))
+		return nil, err
+	}
+	if taskLog.Status != models.TASK_RESULT_RUNNING {
+		err := tx.Model(taskLog).UpdateColumn("duplicate_count", gorm.Expr("duplicate_count + 1")).Error
+		if err == nil {
+			err = tx.Commit().Error
+		} else {
 			tx.Rollback()
-			log.Error("[TaskService::HandleSingleResult] failed to update task status",
-				zap.String("uuid", response.UUID),
-				zap.Uint("task_id", task.ID),
-				zap.Error(err))
-			return nil, err
-		}
-
-		// 提交事务
-		if err := tx.Commit().Error; err != nil {
-			log.Error("[TaskService::HandleSingleResult] failed to commit transaction",
-				zap.String("uuid", response.UUID),
-				zap.Uint("task_id", task.ID),
-				zap.Error(err))
-			return nil, err
 		}
-
-		log.Warn("[TaskService::HandleSingleResult] task execution failed but status updated",
+		log.Warn("[TaskService::HandleSingleResult] ignored result for a finished run",
 			zap.String("uuid", response.UUID),
-			zap.Uint("task_id", task.ID),
-			zap.Int("status", response.Status))
-
-		return &task, nil // 返回更新后的任务，而不是错误
+			zap.Uint("task_id", response.TaskID),
+			zap.Int("log_status", taskLog.Status),
+			zap.Int("status", response.Status),
+			zap.Int("duplicate_count", taskLog.DuplicateCount+1),
+			zap.Error(err))
+		return nil, err
 	}
 
-	// Handle other tasks
-	log.Info("[TaskService::HandleSingleResult] task succeeded",
-		zap.String("uuid", response.UUID),
-		zap.Uint("task_id", task.ID),
-		zap.Int("task_type", response.TaskType))
-
-	// Update task status
-	task.Status = models.TaskStatusMap[models.TASK_STATUS_READY]
-	task.NextStart = response.BackTimestamp + config.TASK_DELAY
-
-	if err := tx.Model(&task).Updates(map[string]interface{}{
-		"status":     models.TaskStatusMap[models.TASK_STATUS_READY],
-		"next_start": response.BackTimestamp + config.TASK_DELAY,
-	}).Error; err != nil {
+	logStatus := models.TASK_RESULT_FAILED
+	if response.Status == models.TASK_RESULT_SUCCESS {
+		logStatus = models.TASK_RESULT_SUCCESS
+	}
+	if err := tx.Model(taskLog).
+		Updates(map[string]interface{}{
+			"status":         logStatus,
+			"msg":            response.Msg,
+			"err_msg":        response.ErrMsg,
+			"back_timestamp": response.BackTimestamp,
+		}).Error; err != nil {
 		tx.Rollback()
-		log.Error("[TaskService::HandleSingleResult] failed to update task",
+		log.Error("[TaskService::HandleSingleResult] failed to update task log",
 			zap.String("uuid", response.UUID),
-			zap.Uint("task_id", task.ID),
+			zap.Uint("task_id", response.TaskID),
 			zap.Error(err))
-		return nil, fmt.Errorf("failed to update task: %w", err)
+		return nil, fmt.Errorf("failed to update task log: %w", err)
 	}
 
-	// Update task log
-	if err := tx.Model(&models.TaskLog{}).
-		Where("uuid = ?", response.UUID).
-		Update("status", models.TASK_RESULT_SUCCESS).Error; err != nil {
+	task, err := handler.HandleResult(tx, response)
+	if err != nil {
 		tx.Rollback()
-		log.Error("[TaskService::HandleSingleResult] failed to update success task log",
+		log.Error("[TaskService::HandleSingleResult] handler failed",
 			zap.String("uuid", response.UUID),
-			zap.Uint("task_id", task.ID),
+			zap.Uint("task_id", response.TaskID),
 			zap.Error(err))
-		return nil, fmt.Errorf("failed to update task log: %w", err)
+		return nil, err
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		log.Error("[TaskService::HandleSingleResult] failed to commit transaction",
 			zap.String("uuid", response.UUID),
-			zap.Uint("task_id", task.ID),
+			zap.Uint("task_id", response.TaskID),
 			zap.Error(err))
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	ts.reindexTask(context.Background(), response.TaskID)
+
+	return task, nil
+}
+
+var (
+	errUnknownRun      = errors.New("no task log for this uuid")
+	errRunTaskMismatch = errors.New("task id does not match the task log")
+	errInvalidResult   = errors.New("invalid result")
+)
+
+// isPermanentResultError reports whether applying the result again can not
+// succeed. Other errors, like a lost connection or a deadlock, are transient.
+func isPermanentResultError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var transitionErr *models.IllegalTransitionError
+	return errors.Is(err, errUnknownRun) || errors.Is(err, errRunTaskMismatch) ||
+		errors.Is(err, errInvalidResult) || errors.Is(err, errUnknownTaskType) ||
+		errors.Is(err, gorm.ErrRecordNotFound) ||
+		errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.As(err, &transitionErr)
+}
+
+// retryTransient calls apply up to attempts times while it fails with a
+// transient error, waiting delay times the attempt in between. The worker of
+// the task waits meanwhile, so later results of the task keep their order.
+func retryTransient(apply func() error, attempts int, delay time.Duration) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = apply()
+		if err == nil || attempt >= attempts || isPermanentResultError(err) {
+			return err
+		}
+		log.Warn("[TaskService::HandleSingleResult] transient error, retrying",
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+		time.Sleep(delay * time.Duration(attempt))
+	}
+}
 
-	return &task, nil
+// lockTaskLog loads the log of the run a result belongs to with a row lock
+// and checks that the result is about the same task
+func lockTaskLog(tx *gorm.DB, response *models.SingleTaskResponse) (*models.TaskLog, error) {
+	var taskLog models.TaskLog
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("uuid = ?", response.UUID).First(&taskLog).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errUnknownRun
+		}
+		return nil, err
+	}
+	if taskLog.TaskID != response.TaskID {
+		return nil, fmt.Errorf("%w: log %d, result %d", errRunTaskMismatch, taskLog.TaskID, response.TaskID)
+	}
+	return &taskLog, nil
 }
+
+// handleReportResult stores the report of a run, the task and its log stay as
+// the result of the run left them
+func (ts *taskService) handleReportResult(response *models.SingleTaskResponse) error {
+	handler, err := getHandler(response.TaskType)
+	if err != nil {
+		return err
+	}
+	reporter, ok := handler.(ReportHandler)
+	if !ok {
+		return fmt.Errorf("%w: task type %d has no reports", errInvalidResult, response.TaskType)
+	}
+	err = ts.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockTaskLog(tx, response); err != nil {
+			return err
+		}
+		return reporter.HandleReport(tx, response)
+	})
+	if err != nil {
+		log.Error("[TaskService::HandleSingleResult] failed to store report",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", response.TaskID),
+			zap.Error(err))
+	}
+	return err
+}
+
+// handleRunningResult moves a dispatched task to running when a node takes its run
+func (ts *taskService) handleRunningResult(response *models.SingleTaskResponse) (*models.Task, error) {
+	if response.TaskID == 0 {
+		return nil, nil // instant tasks have no state
+	}
+	var task *models.Task
+	err := ts.DB.Transaction(func(tx *gorm.DB) error {
+		taskLog, err := lockTaskLog(tx, response)
+		if err != nil {
+			return err
+		}
+		if task, err = lockTask(tx, response.TaskID); err != nil {
+			return err
+		}
+		if taskLog.Status != models.TASK_RESULT_RUNNING ||
+			(task.Status != models.TASK_STATUS_DISPATCHED && task.Status != models.TASK_STATUS_QUEUED) {
+			log.Warn("[TaskService::HandleSingleResult] running message for a task not dispatched",
+				zap.String("uuid", response.UUID),
+				zap.Uint("task_id", task.ID),
+				zap.String("status", task.Status))
+			return nil
+		}
+		return transitionTask(tx, task, models.TASK_STATUS_RUNNING, "taken by node", response.UUID, nil)
+	})
+	if err != nil {
+		log.Error("[TaskService::HandleSingleResult] failed to mark task running",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", response.TaskID),
+			zap.Error(err))
+		return nil, err
+	}
+	return task, nil
+}
+
 func (ts *taskService) ListenFromResultQueue(queueName string) {
 	const reconnectDelay = 5 * time.Second
 
 	log.Info("Listening from result queue", zap.String("queueName", queueName))
 
+	pool := newResultPool(config.RESULT_WORKERS, config.RESULT_WORKER_QUEUE, func(job resultJob) {
+		err := retryTransient(func() error {
+			_, err := ts.HandleSingleResult(&job.response)
+			return err
+		}, config.RESULT_MAX_ATTEMPTS, config.RESULT_RETRY_DELAY)
+		if err != nil {
+			log.Error("Failed to handle single result",
+				zap.Error(err),
+				zap.String("uuid", job.response.UUID),
+				zap.Uint("task_id", job.response.TaskID))
+			ts.deadLetter(job.msg, queueName, err.Error())
+			return
+		}
+		// Acked only once the result is committed
+		if err := job.msg.Ack(false); err != nil {
+			log.Error("Failed to ack result", zap.Error(err), zap.String("uuid", job.response.UUID))
+		}
+	})
+
 	for {
-		resultQueue, err := ts.MQ.ConsumeNormalMessage(queueName)
+		resultQueue, err := ts.MQ.ConsumeAckMessage(queueName, config.RESULT_PREFETCH)
 		if err != nil {
 			log.Error("Failed to consume message from result queue",
 				zap.Error(err),
@@ -178,19 +269,12 @@ func (ts *taskService) ListenFromResultQueue(queueName string) {
 				log.Error("Failed to unmarshal message",
 					zap.Error(err),
 					zap.ByteString("body", msg.Body))
+				ts.deadLetter(msg, queueName, fmt.Sprintf("unmarshal: %v", err))
 				continue
 			}
 
-			// 异步处理消息，避免阻塞消息接收
-			go func(resp models.SingleTaskResponse) {
-				_, err := ts.HandleSingleResult(&resp)
-				if err != nil {
-					log.Error("Failed to handle single result",
-						zap.Error(err),
-						zap.String("uuid", resp.UUID),
-						zap.Uint("task_id", resp.TaskID))
-				}
-			}(response)
+			// Blocks while the worker of this task is busy
+			pool.submit(resultJob{msg: msg, response: response})
 		}
 
 		log.Warn("Message channel closed, attempting to reconnect...",
@@ -0,0 +1,166 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func Test_taskService_HandleSingleResult_duplicates(t *testing.T) {
+	db, task, run := newResultDB(t, models.TASKTYPE_ATTACK)
+	ts := &taskService{DB: db}
+	response := &models.SingleTaskResponse{TaskID: task.ID, UUID: run.UUID, TaskType: models.TASKTYPE_ATTACK,
+		Status: models.TASK_RESULT_SUCCESS, BackTimestamp: 1700000000}
+
+	if got, err := ts.HandleSingleResult(response); err != nil || got == nil {
+		t.Fatalf("HandleSingleResult() = %v, %v, want the task", got, err)
+	}
+	for i := 0; i < 2; i++ {
+		if got, err := ts.HandleSingleResult(response); err != nil || got != nil {
+			t.Fatalf("HandleSingleResult() duplicate = %v, %v, want nil, nil", got, err)
+		}
+	}
+
+	var taskLog models.TaskLog
+	if err := db.First(&taskLog, run.ID).Error; err != nil {
+		t.Fatalf("find task log: %v", err)
+	}
+	if taskLog.Status != models.TASK_RESULT_SUCCESS || taskLog.DuplicateCount != 2 {
+		t.Errorf("task log = status %d with %d duplicates, want %d with 2", taskLog.Status, taskLog.DuplicateCount, models.TASK_RESULT_SUCCESS)
+	}
+	var got models.Task
+	if err := db.First(&got, task.ID).Error; err != nil {
+		t.Fatalf("find task: %v", err)
+	}
+	if got.RunCount != 1 {
+		t.Errorf("run count = %d, want the result applied once", got.RunCount)
+	}
+}
+
+func Test_taskService_HandleSingleResult_running(t *testing.T) {
+	db, task, run := newResultDB(t, models.TASKTYPE_ATTACK)
+	ts := &taskService{DB: db}
+
+	got, err := ts.HandleSingleResult(&models.SingleTaskResponse{TaskID: task.ID, UUID: run.UUID,
+		TaskType: models.TASKTYPE_ATTACK, Status: models.TASK_RESULT_RUNNING})
+	if err != nil {
+		t.Fatalf("HandleSingleResult() error = %v", err)
+	}
+	if got.Status != models.TASK_STATUS_RUNNING {
+		t.Errorf("task status = %s, want %s", got.Status, models.TASK_STATUS_RUNNING)
+	}
+}
+
+func Test_taskService_HandleSingleResult_report(t *testing.T) {
+	db, task, run := newResultDB(t, models.TASKTYPE_ATTACK)
+	ts := &taskService{DB: db}
+	if _, err := ts.HandleSingleResult(&models.SingleTaskResponse{TaskID: task.ID, UUID: run.UUID,
+		TaskType: models.TASKTYPE_ATTACK, Status: models.TASK_RESULT_SUCCESS, BackTimestamp: 1700000000}); err != nil {
+		t.Fatalf("HandleSingleResult() launch error = %v", err)
+	}
+
+	data, _ := json.Marshal(models.BattlePayload{Outcome: models.BATTLE_OUTCOME_WIN, Rounds: 1})
+	report := &models.SingleTaskResponse{TaskID: task.ID, UUID: run.UUID, TaskType: models.TASKTYPE_ATTACK,
+		Status: models.TASK_RESULT_REPORT, BackTimestamp: 1700000000, Data: data}
+	for i := 0; i < 2; i++ { // a redelivered report is stored once
+		if got, err := ts.HandleSingleResult(report); err != nil || got != nil {
+			t.Fatalf("HandleSingleResult() report = %v, %v, want nil, nil", got, err)
+		}
+	}
+
+	var reports []models.BattleReport
+	if err := db.Find(&reports).Error; err != nil {
+		t.Fatalf("find battle reports: %v", err)
+	}
+	if len(reports) != 1 || reports[0].Outcome != models.BATTLE_OUTCOME_WIN || reports[0].TargetID != run.TargetID {
+		t.Errorf("battle reports = %+v, want the report of the run", reports)
+	}
+	var taskLog models.TaskLog
+	if err := db.First(&taskLog, run.ID).Error; err != nil {
+		t.Fatalf("find task log: %v", err)
+	}
+	if taskLog.Status != models.TASK_RESULT_SUCCESS || taskLog.DuplicateCount != 0 {
+		t.Errorf("task log = status %d with %d duplicates, want the result of the launch", taskLog.Status, taskLog.DuplicateCount)
+	}
+	var got models.Task
+	if err := db.First(&got, task.ID).Error; err != nil {
+		t.Fatalf("find task: %v", err)
+	}
+	if got.Status != models.TASK_STATUS_RETURNED || got.RunCount != 1 {
+		t.Errorf("task = status %s, run count %d, want the report to leave it alone", got.Status, got.RunCount)
+	}
+
+	// Transports have no report
+	report.TaskType = models.TASKTYPE_TRANSPORT
+	if _, err := ts.HandleSingleResult(report); !errors.Is(err, errInvalidResult) {
+		t.Errorf("HandleSingleResult() transport report error = %v, want %v", err, errInvalidResult)
+	}
+}
+
+func Test_lockTaskLog(t *testing.T) {
+	db, task, run := newResultDB(t, models.TASKTYPE_ATTACK)
+	tests := []struct {
+		name     string
+		response *models.SingleTaskResponse
+		wantErr  error
+	}{
+		{"run of the task", &models.SingleTaskResponse{TaskID: task.ID, UUID: run.UUID}, nil},
+		{"unknown run", &models.SingleTaskResponse{TaskID: task.ID, UUID: "run-9"}, errUnknownRun},
+		{"run of another task", &models.SingleTaskResponse{TaskID: task.ID + 1, UUID: run.UUID}, errRunTaskMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lockTaskLog(db, tt.response)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("lockTaskLog() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.ID != run.ID {
+				t.Errorf("lockTaskLog() = log %d, want %d", got.ID, run.ID)
+			}
+		})
+	}
+
+	// A rejected result leaves the run untouched
+	ts := &taskService{DB: db}
+	if _, err := ts.HandleSingleResult(&models.SingleTaskResponse{TaskID: task.ID + 1, UUID: run.UUID,
+		TaskType: models.TASKTYPE_ATTACK, Status: models.TASK_RESULT_SUCCESS}); !errors.Is(err, errRunTaskMismatch) {
+		t.Fatalf("HandleSingleResult() error = %v, want %v", err, errRunTaskMismatch)
+	}
+	if status := logStatus(t, db, run.UUID); status != models.TASK_RESULT_RUNNING {
+		t.Errorf("log status = %d, want %d", status, models.TASK_RESULT_RUNNING)
+	}
+}
+
+func Test_retryTransient(t *testing.T) {
+	transient := errors.New("connection reset")
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{"applied at once", []error{nil}, 1, nil},
+		{"transient then applied", []error{transient, nil}, 2, nil},
+		{"transient every time", []error{transient, transient, transient, transient}, 3, transient},
+		{"unknown run", []error{errUnknownRun, nil}, 1, errUnknownRun},
+		{"mismatch", []error{fmt.Errorf("%w: log 1, result 2", errRunTaskMismatch), nil}, 1, errRunTaskMismatch},
+		{"unparsable payload", []error{fmt.Errorf("failed to unmarshal fleet: %w", json.Unmarshal([]byte("{"), &struct{}{})), nil}, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retryTransient(func() error {
+				calls++
+				return tt.errs[calls-1]
+			}, 3, 0)
+			if calls != tt.wantCalls {
+				t.Errorf("retryTransient() calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("retryTransient() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
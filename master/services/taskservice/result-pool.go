@@ -0,0 +1,54 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/streadway/amqp"
+)
+
+type resultJob struct {
+	msg      amqp.Delivery
+	response models.SingleTaskResponse
+}
+
+// resultPool runs result handlers on a fixed number of workers. Each worker has
+// its own bounded queue and a task always maps to the same worker, so results of
+// one task are applied in order and never concurrently. submit blocks while the
+// queue is full, RabbitMQ then keeps the backlog up to the prefetch limit.
+type resultPool struct {
+	shards []chan resultJob
+}
+
+func newResultPool(workers, queueSize int, handle func(resultJob)) *resultPool {
+	if workers < 1 {
+		workers = 1
+	}
+	pool := &resultPool{shards: make([]chan resultJob, workers)}
+	for i := range pool.shards {
+		shard := make(chan resultJob, queueSize)
+		pool.shards[i] = shard
+		go func() {
+			for job := range shard {
+				handle(job)
+			}
+		}()
+	}
+	return pool
+}
+
+func (p *resultPool) submit(job resultJob) {
+	p.shards[shardOf(&job.response, len(p.shards))] <- job
+}
+
+// shardOf maps a result to a worker by task, instant results have no task and spread by uuid
+func shardOf(response *models.SingleTaskResponse, shards int) int {
+	key := response.UUID
+	if response.TaskID != 0 {
+		key = strconv.FormatUint(uint64(response.TaskID), 10)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(shards))
+}
@@ -0,0 +1,40 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"sync"
+	"testing"
+)
+
+func Test_resultPool_keepsTaskOrder(t *testing.T) {
+	const tasks, results = 5, 50
+	var (
+		mu   sync.Mutex
+		seen = map[uint][]int{}
+		wg   sync.WaitGroup
+	)
+	pool := newResultPool(3, 1, func(job resultJob) {
+		mu.Lock()
+		seen[job.response.TaskID] = append(seen[job.response.TaskID], job.response.Status)
+		mu.Unlock()
+		wg.Done()
+	})
+	for i := 0; i < results; i++ {
+		for id := uint(1); id <= tasks; id++ {
+			wg.Add(1)
+			pool.submit(resultJob{response: models.SingleTaskResponse{TaskID: id, Status: i}})
+		}
+	}
+	wg.Wait()
+
+	for id := uint(1); id <= tasks; id++ {
+		if len(seen[id]) != results {
+			t.Fatalf("task %d: got %d results, want %d", id, len(seen[id]), results)
+		}
+		for i, status := range seen[id] {
+			if status != i {
+				t.Fatalf("task %d: result %d applied out of order", id, i)
+			}
+		}
+	}
+}
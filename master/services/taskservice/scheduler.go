@@ -0,0 +1,246 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/config"
+	"GalaxyEmpireWeb/models"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// The schedule index is a Redis sorted set of the task ids the generator has
+// to look at, scored by the Unix time they become due. Services reindex a task
+// after changing it, the scheduler only loads the due ones instead of
+// preloading every account.
+
+const scheduleBatchSize = 500
+
+// scheduleScore returns when the generator has to look at a task, ok is false when it never has to
+func scheduleScore(task *models.Task) (score int64, ok bool) {
+	if !task.Enabled {
+		return 0, false
+	}
+	if models.IsDispatchable(task.Status) {
+		return task.NextStart - int64(config.QUEUE_THRESHOLD/time.Second), true
+	}
+	return 0, false // in flight tasks come back through a result or the reaper
+}
+
+// scheduleEntries loads the columns scheduleScore needs for the tasks of unexpired accounts
+func scheduleEntries(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Model(&models.Task{}).
+		Select("tasks.id, tasks.enabled, tasks.status, tasks.next_start").
+		Joins("JOIN accounts ON accounts.id = tasks.account_id AND accounts.deleted_at IS NULL AND accounts.expire_at > ?", now)
+}
+
+// rescheduleScore is scheduleScore after a scheduler pass. A task still due
+// was refused by the generator, it is looked at again after
+// TASK_GENERATOR_INTERVAL instead of on every pass, where it would keep
+// healthy tasks out of the batch.
+func rescheduleScore(task *models.Task, now time.Time) (score int64, ok bool) {
+	score, ok = scheduleScore(task)
+	if ok && score <= now.Unix() {
+		score = now.Add(config.TASK_GENERATOR_INTERVAL).Unix()
+	}
+	return score, ok
+}
+
+func indexTask(ctx context.Context, rdb redis.Cmdable, task *models.Task) error {
+	score, ok := scheduleScore(task)
+	return setTaskScore(ctx, rdb, task.ID, score, ok)
+}
+
+// setTaskScore adds a task to the index with score, or removes it when ok is false
+func setTaskScore(ctx context.Context, rdb redis.Cmdable, taskID uint, score int64, ok bool) error {
+	member := strconv.Itoa(int(taskID))
+	if ok {
+		return rdb.ZAdd(ctx, config.SCHEDULE_INDEX_KEY, redis.Z{Score: float64(score), Member: member}).Err()
+	}
+	return rdb.ZRem(ctx, config.SCHEDULE_INDEX_KEY, member).Err()
+}
+
+// reindexTask refreshes the index entry of a task from the DB and wakes the scheduler.
+// It is a no-op without Redis, the polling generator does not need the index.
+func (ts *taskService) reindexTask(ctx context.Context, taskID uint) {
+	if ts.RDB == nil || taskID == 0 {
+		return
+	}
+	var task models.Task
+	err := scheduleEntries(ts.DB, time.Now()).Where("tasks.id = ?", taskID).Take(&task).Error
+	if err == gorm.ErrRecordNotFound {
+		task = models.Task{Model: gorm.Model{ID: taskID}} // deleted or expired, never due
+	} else if err != nil {
+		log.Error("[TaskService::Scheduler] failed to load task for index", zap.Uint("task_id", taskID), zap.Error(err))
+		return
+	}
+	if err := indexTask(ctx, ts.RDB, &task); err != nil {
+		log.Error("[TaskService::Scheduler] failed to index task", zap.Uint("task_id", taskID), zap.Error(err))
+		return
+	}
+	if err := ts.RDB.Publish(ctx, config.SCHEDULE_WAKEUP_CHANNEL, taskID).Err(); err != nil {
+		log.Warn("[TaskService::Scheduler] failed to wake scheduler", zap.Uint("task_id", taskID), zap.Error(err))
+	}
+}
+
+// resyncSchedule rebuilds the whole index from the DB, catching changes made
+// outside the services and entries lost with Redis
+func (ts *taskService) resyncSchedule(ctx context.Context) error {
+	var tasks []models.Task
+	if err := scheduleEntries(ts.DB, time.Now()).Find(&tasks).Error; err != nil {
+		return err
+	}
+	var entries []redis.Z
+	for _, task := range tasks {
+		if score, ok := scheduleScore(&task); ok {
+			entries = append(entries, redis.Z{Score: float64(score), Member: strconv.Itoa(int(task.ID))})
+		}
+	}
+	pipe := ts.RDB.TxPipeline()
+	pipe.Del(ctx, config.SCHEDULE_INDEX_KEY)
+	if len(entries) > 0 {
+		pipe.ZAdd(ctx, config.SCHEDULE_INDEX_KEY, entries...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	log.Info("[TaskService::Scheduler] schedule index rebuilt", zap.Int("tasks", len(entries)))
+	return nil
+}
+
+// runDueTasks hands the due tasks to GenerateTaskForAccount and reindexes them
+func (ts *taskService) runDueTasks(ctx context.Context, now time.Time) error {
+	members, err := ts.RDB.ZRangeByScore(ctx, config.SCHEDULE_INDEX_KEY, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: scheduleBatchSize,
+	}).Result()
+	if err != nil || len(members) == 0 {
+		return err
+	}
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.Atoi(member)
+		if err != nil {
+			ts.RDB.ZRem(ctx, config.SCHEDULE_INDEX_KEY, member)
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	log.Info("[TaskService::Scheduler] running due tasks", zap.Int("tasks", len(ids)))
+
+	var tasks []models.Task
+	if err := ts.DB.Preload("Targets").Preload("Fleet").Preload("FleetPreset").Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+		return err
+	}
+	accountIDs := make([]uint, 0, len(tasks))
+	for _, task := range tasks {
+		accountIDs = append(accountIDs, task.AccountID)
+	}
+	var accounts []*models.Account
+	if err := ts.DB.Where("id IN ? AND expire_at > ?", accountIDs, now).Find(&accounts).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]*models.Account, len(accounts))
+	for _, account := range accounts {
+		byID[account.ID] = account
+	}
+	for _, task := range tasks {
+		if account, ok := byID[task.AccountID]; ok {
+			account.Tasks = append(account.Tasks, task)
+		}
+	}
+	for _, account := range accounts {
+		if err := ts.GenerateTaskForAccount(account); err != nil {
+			log.Error("[TaskService::Scheduler] failed to generate task for account",
+				zap.Uint("account_id", account.ID), zap.Error(err))
+		}
+	}
+
+	// Tasks missing from the reload were deleted or belong to expired accounts
+	var current []models.Task
+	if err := scheduleEntries(ts.DB, now).Where("tasks.id IN ?", ids).Find(&current).Error; err != nil {
+		return err
+	}
+	found := make(map[uint]bool, len(current))
+	pipe := ts.RDB.Pipeline()
+	for i := range current {
+		found[current[i].ID] = true
+		score, ok := rescheduleScore(&current[i], now)
+		if err := setTaskScore(ctx, pipe, current[i].ID, score, ok); err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		if !found[id] {
+			pipe.ZRem(ctx, config.SCHEDULE_INDEX_KEY, strconv.Itoa(int(id)))
+		}
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// untilNextDue returns how long the scheduler may sleep before a task becomes due
+func (ts *taskService) untilNextDue(ctx context.Context, now time.Time) (time.Duration, error) {
+	next, err := ts.RDB.ZRangeWithScores(ctx, config.SCHEDULE_INDEX_KEY, 0, 0).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(next) == 0 {
+		return config.SCHEDULE_RESYNC_INTERVAL, nil
+	}
+	return time.Unix(int64(next[0].Score), 0).Sub(now), nil
+}
+
+// ScheduleLoop replaces GenerateTaskLoop when Redis is available. It sleeps
+// until the next task is due or the index changes, on the leader only.
+func (ts *taskService) ScheduleLoop() {
+	time.Sleep(5 * time.Second)
+	log.Info("[TaskService::ScheduleLoop] start task scheduler loop")
+	ctx := context.Background()
+	wakeup := ts.RDB.Subscribe(ctx, config.SCHEDULE_WAKEUP_CHANNEL)
+	defer wakeup.Close()
+
+	var lastSync time.Time
+	for {
+		wait := config.LEADER_LEASE_TTL / 3
+		if ts.leader == nil || ts.leader.IsLeader() {
+			now := time.Now()
+			// A new leader does not trust the index it did not maintain
+			if now.Sub(lastSync) >= config.SCHEDULE_RESYNC_INTERVAL {
+				if err := ts.resyncSchedule(ctx); err != nil {
+					log.Error("[TaskService::ScheduleLoop] failed to rebuild schedule index", zap.Error(err))
+				} else {
+					lastSync = now
+				}
+			}
+			if err := ts.runDueTasks(ctx, now); err != nil {
+				log.Error("[TaskService::ScheduleLoop] failed to run due tasks", zap.Error(err))
+			}
+			next, err := ts.untilNextDue(ctx, time.Now())
+			if err != nil {
+				log.Error("[TaskService::ScheduleLoop] failed to read schedule index", zap.Error(err))
+				next = config.TASK_GENERATOR_INTERVAL
+			}
+			wait = min(next, config.SCHEDULE_RESYNC_INTERVAL-time.Since(lastSync))
+			wait = max(wait, config.SCHEDULE_MIN_INTERVAL)
+		} else {
+			lastSync = time.Time{}
+		}
+		log.Debug("[TaskService::ScheduleLoop] sleeping", zap.Duration("wait", wait))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-wakeup.Channel():
+			time.Sleep(config.SCHEDULE_MIN_INTERVAL) // let a burst of changes settle
+			for len(wakeup.Channel()) > 0 {
+				<-wakeup.Channel()
+			}
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
@@ -0,0 +1,90 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/config"
+	"GalaxyEmpireWeb/models"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func Test_rescheduleScore(t *testing.T) {
+	now := time.Now()
+	threshold := int64(config.QUEUE_THRESHOLD / time.Second)
+	refused := &models.Task{Enabled: true, Status: models.TASK_STATUS_READY, NextStart: now.Unix()}
+	if score, ok := rescheduleScore(refused, now); !ok || score != now.Add(config.TASK_GENERATOR_INTERVAL).Unix() {
+		t.Errorf("refused task score = %d, %v, want %d", score, ok, now.Add(config.TASK_GENERATOR_INTERVAL).Unix())
+	}
+	later := &models.Task{Enabled: true, Status: models.TASK_STATUS_READY, NextStart: now.Unix() + threshold + 600}
+	if score, ok := rescheduleScore(later, now); !ok || score != now.Unix()+600 {
+		t.Errorf("later task score = %d, %v, want %d", score, ok, now.Unix()+600)
+	}
+	queued := &models.Task{Enabled: true, Status: models.TASK_STATUS_QUEUED, NextStart: now.Unix()}
+	if _, ok := rescheduleScore(queued, now); ok {
+		t.Errorf("queued task kept in the index")
+	}
+}
+
+// Test_rescheduleScore_brokenTasksDoNotStarve checks that rescheduleScore
+// moves refused tasks behind the rest of the index. It replays the batch
+// order of runDueTasks over a map instead of Redis and stands in validateTask
+// for the generator: more than a batch of refused tasks come before a valid
+// one, which must still be picked within a few passes.
+func Test_rescheduleScore_brokenTasksDoNotStarve(t *testing.T) {
+	now := time.Now()
+	threshold := int64(config.QUEUE_THRESHOLD / time.Second)
+	tasks := map[uint]*models.Task{}
+	index := map[uint]int64{}
+	for id := uint(1); id <= scheduleBatchSize+50; id++ {
+		// No targets, Validate refuses the task on every pass
+		tasks[id] = &models.Task{Model: gorm.Model{ID: id}, Enabled: true, Status: models.TASK_STATUS_READY,
+			TaskType: models.TASKTYPE_ATTACK, NextStart: now.Unix() - 100 + threshold}
+	}
+	validID := uint(scheduleBatchSize + 51)
+	tasks[validID] = &models.Task{Model: gorm.Model{ID: validID}, Enabled: true, Status: models.TASK_STATUS_READY,
+		TaskType: models.TASKTYPE_ATTACK, NextStart: now.Unix() - 10 + threshold,
+		Targets: []models.Target{{Galaxy: 1, System: 1, Planet: 1}}}
+	for id, task := range tasks {
+		index[id], _ = scheduleScore(task)
+	}
+
+	pass := func(now time.Time) {
+		var due []uint
+		for id, score := range index {
+			if score <= now.Unix() {
+				due = append(due, id)
+			}
+		}
+		// ZRANGEBYSCORE order, ties by member
+		sort.Slice(due, func(i, j int) bool {
+			if index[due[i]] != index[due[j]] {
+				return index[due[i]] < index[due[j]]
+			}
+			return strconv.Itoa(int(due[i])) < strconv.Itoa(int(due[j]))
+		})
+		if len(due) > scheduleBatchSize {
+			due = due[:scheduleBatchSize]
+		}
+		for _, id := range due {
+			task := tasks[id]
+			if validateTask(task) == nil {
+				task.Status = models.TASK_STATUS_QUEUED
+			}
+			if score, ok := rescheduleScore(task, now); ok {
+				index[id] = score
+			} else {
+				delete(index, id)
+			}
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		pass(now.Add(time.Duration(i) * config.SCHEDULE_MIN_INTERVAL))
+	}
+	if status := tasks[validID].Status; status != models.TASK_STATUS_QUEUED {
+		t.Errorf("valid task status = %s after 3 passes, want %s", status, models.TASK_STATUS_QUEUED)
+	}
+}
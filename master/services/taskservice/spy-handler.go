@@ -0,0 +1,86 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// spyHandler handles espionage missions, fleet runs of probes whose report is
+// stored for the target
+type spyHandler struct {
+	fleetHandler
+}
+
+func (h *spyHandler) Validate(task *models.Task) error {
+	if err := h.fleetHandler.Validate(task); err != nil {
+		return err
+	}
+	if task.Ships().Satellite <= 0 { // ship210, the espionage probe
+		return errors.New("spy tasks need probes in their fleet")
+	}
+	return nil
+}
+
+func (h *spyHandler) HandleResult(tx *gorm.DB, response *models.SingleTaskResponse) (*models.Task, error) {
+	task, err := h.fleetHandler.HandleResult(tx, response)
+	if err != nil || response.Status != models.TASK_RESULT_SUCCESS {
+		return task, err
+	}
+	// Older nodes send the report along with the result
+	if err := h.HandleReport(tx, response); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (h *spyHandler) HandleReport(tx *gorm.DB, response *models.SingleTaskResponse) error {
+	if err := recordSpyReport(tx, response); err != nil {
+		log.Error("[spyHandler::HandleReport] failed to record spy report",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", response.TaskID),
+			zap.Error(err))
+		return fmt.Errorf("failed to record spy report: %w", err)
+	}
+	return nil
+}
+
+// recordSpyReport saves the report of a successful run under the coordinates
+// of the target it was sent to
+func recordSpyReport(tx *gorm.DB, response *models.SingleTaskResponse) error {
+	var data models.SpyReportData
+	if !decodePayload(response, &data) {
+		return nil // the run succeeded, only its report is lost
+	}
+	taskLog, err := loadRun(tx, response.UUID)
+	if err != nil {
+		return err
+	}
+	var target models.Target
+	if err := tx.Unscoped().First(&target, taskLog.TargetID).Error; err != nil {
+		return fmt.Errorf("failed to load target %d: %w", taskLog.TargetID, err)
+	}
+	if data.ReportedAt == 0 {
+		data.ReportedAt = time.Now().Unix()
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SpyReport{
+		AccountID:  taskLog.AccountID,
+		TaskID:     response.TaskID,
+		UUID:       response.UUID,
+		TargetID:   target.ID,
+		Galaxy:     target.Galaxy,
+		System:     target.System,
+		Planet:     target.Planet,
+		IsMoon:     target.Is_moon,
+		ReportedAt: data.ReportedAt,
+		Resources:  data.Resources,
+		Fleet:      data.Fleet,
+		Defense:    data.Defense,
+		Buildings:  data.Buildings,
+	}).Error
+}
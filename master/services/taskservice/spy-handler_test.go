@@ -0,0 +1,75 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func Test_taskService_AddTask_spyWithPreset(t *testing.T) {
+	ts, _, _, ctx := newTemplateService(t)
+	probes := &models.FleetPreset{UserID: 1, Name: "probes", Ships: models.FleetDTO{Satellite: 2}}
+	fighters := &models.FleetPreset{UserID: 1, Name: "fighters", Ships: models.FleetDTO{LightFighter: 5}}
+	for _, preset := range []*models.FleetPreset{probes, fighters} {
+		if err := ts.DB.Create(preset).Error; err != nil {
+			t.Fatalf("create preset: %v", err)
+		}
+	}
+	newSpyTask := func(presetID uint) *models.Task {
+		return &models.Task{Name: "spy", AccountID: 5, TaskType: models.TASKTYPE_SPY, Enabled: true, Repeat: 1,
+			StartPlanetID: 7, FleetPresetID: presetID, Targets: []models.Target{{Galaxy: 1, System: 5, Planet: 5}}}
+	}
+
+	// The task has no fleet of its own, the probes come from the preset
+	task := newSpyTask(probes.ID)
+	if serviceErr := ts.AddTask(ctx, task); serviceErr != nil {
+		t.Fatalf("AddTask() with probes in the preset error = %v", serviceErr)
+	}
+	if count := countTasks(t, ts.DB); count != 1 {
+		t.Errorf("tasks = %d, want 1", count)
+	}
+	if serviceErr := ts.AddTask(ctx, newSpyTask(fighters.ID)); serviceErr == nil || serviceErr.StatusCode() != http.StatusBadRequest {
+		t.Errorf("AddTask() without probes in the preset error = %v, want %d", serviceErr, http.StatusBadRequest)
+	}
+
+	task.FleetPresetID = fighters.ID
+	if serviceErr := ts.UpdateTask(ctx, task); serviceErr == nil || serviceErr.StatusCode() != http.StatusBadRequest {
+		t.Errorf("UpdateTask() to a preset without probes error = %v, want %d", serviceErr, http.StatusBadRequest)
+	}
+}
+
+func Test_taskService_HandleSingleResult_spyReport(t *testing.T) {
+	db, task, run := newResultDB(t, models.TASKTYPE_SPY)
+	if err := db.AutoMigrate(&models.SpyReport{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	ts := &taskService{DB: db}
+	if _, err := ts.HandleSingleResult(&models.SingleTaskResponse{TaskID: task.ID, UUID: run.UUID,
+		TaskType: models.TASKTYPE_SPY, Status: models.TASK_RESULT_SUCCESS, BackTimestamp: 1700000000}); err != nil {
+		t.Fatalf("HandleSingleResult() launch error = %v", err)
+	}
+
+	data, _ := json.Marshal(models.SpyReportData{ReportedAt: 1699999000, Resources: models.SpyResources{Metal: 5000},
+		Fleet: map[string]int64{"cargo": 2}})
+	report := &models.SingleTaskResponse{TaskID: task.ID, UUID: run.UUID, TaskType: models.TASKTYPE_SPY,
+		Status: models.TASK_RESULT_REPORT, BackTimestamp: 1700000000, Data: data}
+	for i := 0; i < 2; i++ {
+		if _, err := ts.HandleSingleResult(report); err != nil {
+			t.Fatalf("HandleSingleResult() report error = %v", err)
+		}
+	}
+
+	var reports []models.SpyReport
+	if err := db.Find(&reports).Error; err != nil {
+		t.Fatalf("find spy reports: %v", err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("spy reports = %d, want 1", len(reports))
+	}
+	got := reports[0]
+	if got.Galaxy != 1 || got.System != 2 || got.Planet != 3 || got.ReportedAt != 1699999000 ||
+		got.Resources.Metal != 5000 || got.Fleet["cargo"] != 2 {
+		t.Errorf("spy report = %+v, want the report at the target of the run", got)
+	}
+}
@@ -0,0 +1,73 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ListSpyReports returns a page of the spy reports of an account, newest first,
+// and the number of reports matching the filter
+func (ts *taskService) ListSpyReports(ctx context.Context, accountID uint, filter *models.SpyReportFilter) ([]models.SpyReport, int64, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ListSpyReports", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("accountID", accountID))
+
+	if serviceErr := ts.checkAccountPermission(ctx, accountID, "read"); serviceErr != nil {
+		return nil, 0, serviceErr
+	}
+	query := ts.DB.Model(&models.SpyReport{}).Where("account_id = ?", accountID)
+	if target := filter.Target; target != nil {
+		query = query.Where(map[string]interface{}{ // quoted by gorm, system is reserved in MySQL 8
+			"galaxy":  target.Galaxy,
+			"system":  target.System,
+			"planet":  target.Planet,
+			"is_moon": target.Is_moon,
+		})
+	}
+	if filter.Since != 0 {
+		query = query.Where("reported_at >= ?", filter.Since)
+	}
+	if filter.Until != 0 {
+		query = query.Where("reported_at < ?", filter.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Error("[TaskService] ListSpyReports", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Count Spy Report Error", err)
+	}
+	var reports []models.SpyReport
+	if err := query.Order("reported_at DESC, id DESC").
+		Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&reports).Error; err != nil {
+		log.Error("[TaskService] ListSpyReports", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Get Spy Report Error", err)
+	}
+	return reports, total, nil
+}
+
+// GetSpyReport returns a spy report of an account the user in ctx may read
+func (ts *taskService) GetSpyReport(ctx context.Context, reportID uint) (*models.SpyReport, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] GetSpyReport", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("reportID", reportID))
+
+	var report models.SpyReport
+	if err := ts.DB.First(&report, reportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewServiceError(http.StatusNotFound, "Spy Report Not Found", err)
+		}
+		log.Error("[TaskService] GetSpyReport", zap.String("traceID", traceID), zap.Uint("reportID", reportID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Spy Report Error", err)
+	}
+	if serviceErr := ts.checkAccountPermission(ctx, report.AccountID, "read"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	return &report, nil
+}
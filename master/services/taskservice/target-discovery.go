@@ -0,0 +1,236 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ProposeTargets ranks the planets of the universe catalog of the server of a
+// task that fit the criteria. Positions of the task and of the account are left out.
+func (ts *taskService) ProposeTargets(ctx context.Context, taskID uint, criteria *models.TargetCriteria) ([]models.TargetProposal, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ProposeTargets", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
+
+	if err := criteria.Validate(); err != nil {
+		return nil, utils.NewServiceError(http.StatusBadRequest, "Wrong Target Criteria", err)
+	}
+	task, serviceErr := ts.getAllowedTask(ctx, taskID, "read")
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	var account models.Account
+	if err := ts.DB.Select("server").First(&account, task.AccountID).Error; err != nil {
+		log.Error("[TaskService] ProposeTargets", zap.String("traceID", traceID), zap.Uint("accountID", task.AccountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Account Error", err)
+	}
+
+	exclude := map[string]bool{}
+	var targets []models.Target
+	if err := ts.DB.Where("task_id = ?", task.ID).Find(&targets).Error; err != nil {
+		log.Error("[TaskService] ProposeTargets", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Target Error", err)
+	}
+	for _, target := range targets {
+		exclude[models.PositionKey(target.Galaxy, target.System, target.Planet)] = true
+	}
+	inventory, err := loadFleetInventory(ts.DB, task.AccountID)
+	if err != nil {
+		log.Error("[TaskService] ProposeTargets", zap.String("traceID", traceID), zap.Uint("accountID", task.AccountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Fleet Inventory Error", err)
+	}
+	for _, planet := range inventory {
+		if own, err := models.ParsePosition(planet.Position); err == nil {
+			exclude[models.PositionKey(own.Galaxy, own.System, own.Planet)] = true
+		}
+	}
+
+	if err := ts.DB.Where("start_task_id = ?", task.ID).Limit(1).Find(&task.StartPlanet).Error; err != nil {
+		log.Error("[TaskService] ProposeTargets", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Start Planet Error", err)
+	}
+	origin, err := taskOrigin(task, criteria, inventory)
+	if err != nil {
+		return nil, utils.NewServiceError(http.StatusBadRequest, "Unknown Start Planet", err)
+	}
+	planets, err := loadUniverseAround(ts.DB, account.Server, origin, criteria)
+	if err != nil {
+		log.Error("[TaskService] ProposeTargets", zap.String("traceID", traceID), zap.String("server", account.Server), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Universe Error", err)
+	}
+	reports, err := loadLastSpyReports(ts.DB, task.AccountID)
+	if err != nil {
+		log.Error("[TaskService] ProposeTargets", zap.String("traceID", traceID), zap.Uint("accountID", task.AccountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Spy Report Error", err)
+	}
+	return models.ProposeTargets(origin, planets, reports, exclude, *criteria), nil
+}
+
+// taskOrigin is where distances of a proposal are measured from: the origin
+// of the criteria, otherwise the start planet of the task as last queried,
+// otherwise the start planet stored with the task
+func taskOrigin(task *models.Task, criteria *models.TargetCriteria, inventory map[uint]models.FleetInventory) (models.Target, error) {
+	if criteria.Origin != nil {
+		return *criteria.Origin, nil
+	}
+	planet, ok := inventory[task.StartPlanetID]
+	if !ok {
+		if start := task.StartPlanet; start.Galaxy > 0 && start.System > 0 && start.Planet > 0 {
+			return models.Target{Galaxy: start.Galaxy, System: start.System, Planet: start.Planet, Is_moon: start.Is_moon}, nil
+		}
+		return models.Target{}, errors.New("start planet position unknown, query the fleet or give an origin")
+	}
+	origin, err := models.ParsePosition(planet.Position)
+	if err != nil {
+		return models.Target{}, fmt.Errorf("start planet position: %w", err)
+	}
+	return origin, nil
+}
+
+// loadUniverseAround loads the planets of a server that may lie within the
+// max distance of the criteria, the exact distance is checked by the caller
+func loadUniverseAround(db *gorm.DB, server string, origin models.Target, criteria *models.TargetCriteria) ([]models.UniversePlanet, error) {
+	galaxies := criteria.MaxDistance / models.DISTANCE_GALAXY
+	query := db.Where("server = ? AND galaxy BETWEEN ? AND ?", server, origin.Galaxy-galaxies, origin.Galaxy+galaxies)
+	if galaxies == 0 {
+		systems := 0
+		if criteria.MaxDistance >= models.DISTANCE_SYSTEM {
+			systems = (criteria.MaxDistance - models.DISTANCE_SYSTEM) / models.DISTANCE_PER_SYSTEM
+		}
+		query = query.Where("solar BETWEEN ? AND ?", origin.System-systems, origin.System+systems)
+	}
+	if criteria.InactiveOnly {
+		query = query.Where("inactive = ? OR long_inactive = ?", true, true)
+	}
+	if criteria.HasMoon != nil {
+		query = query.Where("has_moon = ?", *criteria.HasMoon)
+	}
+	var planets []models.UniversePlanet
+	err := query.Find(&planets).Error
+	return planets, err
+}
+
+// loadLastSpyReports returns the last spy report of an account about every
+// planet it spied, by position
+func loadLastSpyReports(db *gorm.DB, accountID uint) (map[string]models.SpyReport, error) {
+	var rows []models.SpyReport
+	if err := db.Select("id", "galaxy", "system", "planet", "reported_at", "resource_metal", "resource_crystal", "resource_deuterium").
+		Where("account_id = ? AND is_moon = ?", accountID, false).
+		Order("reported_at DESC, id DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	reports := make(map[string]models.SpyReport, len(rows))
+	for _, row := range rows {
+		key := models.PositionKey(row.Galaxy, row.System, row.Planet)
+		if _, ok := reports[key]; !ok {
+			reports[key] = row
+		}
+	}
+	return reports, nil
+}
+
+// AcceptTargets adds proposed targets to a task, or replaces its targets when
+// replace is set. Positions the task already has are skipped. The task with its
+// new targets is validated like an updated task before anything is saved.
+func (ts *taskService) AcceptTargets(ctx context.Context, taskID uint, targets []models.Target, replace bool) ([]models.Target, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] AcceptTargets", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID),
+		zap.Int("targets", len(targets)), zap.Bool("replace", replace))
+
+	for _, target := range targets {
+		if target.Galaxy < 1 || target.System < 1 || target.Planet < 1 {
+			return nil, utils.NewServiceError(http.StatusBadRequest, "Wrong Target", fmt.Errorf("target %s is not a position", target.String()))
+		}
+		if target.Weight < 0 {
+			return nil, utils.NewServiceError(http.StatusBadRequest, "Wrong Target", fmt.Errorf("target %s has a negative weight", target.String()))
+		}
+	}
+	if replace && len(targets) == 0 {
+		return nil, utils.NewServiceError(http.StatusBadRequest, "Wrong Target", errors.New("a task needs at least one target"))
+	}
+	if _, serviceErr := ts.getAllowedTask(ctx, taskID, "write"); serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	var saved []models.Target
+	var invalid *utils.ServiceError
+	err := ts.DB.Transaction(func(tx *gorm.DB) error {
+		task, err := lockTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		if replace {
+			if err := tx.Where("task_id = ?", task.ID).Delete(&models.Target{}).Error; err != nil {
+				return err
+			}
+			// The selection state pointed into the old list
+			if err := tx.Model(task).Updates(map[string]interface{}{"next_index": 0, "retry_target_id": 0}).Error; err != nil {
+				return err
+			}
+		}
+		var existing []models.Target
+		if err := tx.Where("task_id = ?", task.ID).Find(&existing).Error; err != nil {
+			return err
+		}
+		known := map[string]bool{}
+		for _, target := range existing {
+			known[target.String()] = true
+		}
+		var added []models.Target
+		for _, target := range targets {
+			if known[target.String()] {
+				continue
+			}
+			known[target.String()] = true
+			added = append(added, models.Target{
+				Galaxy:  target.Galaxy,
+				System:  target.System,
+				Planet:  target.Planet,
+				Is_moon: target.Is_moon,
+				Weight:  target.Weight,
+				TaskID:  task.ID,
+			})
+		}
+		if len(added) > 0 {
+			if err := tx.Create(&added).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("task_id = ?", task.ID).Order("id").Find(&saved).Error; err != nil {
+			return err
+		}
+		// The handler checks the fleet along with the targets
+		if err := tx.Preload("Fleet").Preload("FleetPreset").First(task, task.ID).Error; err != nil {
+			return err
+		}
+		task.Targets = saved
+		if err := validateTask(task); err != nil {
+			invalid = utils.NewServiceError(http.StatusBadRequest, "Invalid Task", err)
+			return err
+		}
+		if serviceErr := ts.checkAccountPlanets(ctx, tx, task, false); serviceErr != nil {
+			invalid = serviceErr
+			return serviceErr
+		}
+		return nil
+	})
+	if invalid != nil {
+		log.Warn("[TaskService] AcceptTargets invalid task", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, invalid
+	}
+	if err != nil {
+		log.Error("[TaskService] AcceptTargets", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Update Target Error", err)
+	}
+	ts.reindexTask(ctx, taskID)
+	log.Info("[TaskService] AcceptTargets Succeed", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Int("targets", len(saved)))
+	return saved, nil
+}
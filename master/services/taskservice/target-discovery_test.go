@@ -0,0 +1,36 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"testing"
+)
+
+func Test_taskOrigin(t *testing.T) {
+	inventory := map[uint]models.FleetInventory{7: {PlanetID: 7, Position: "2:40:8:0"}}
+	stored := models.Target{Galaxy: 3, System: 100, Planet: 5}
+	given := &models.Target{Galaxy: 1, System: 1, Planet: 1}
+	tests := []struct {
+		name      string
+		task      *models.Task
+		criteria  *models.TargetCriteria
+		inventory map[uint]models.FleetInventory
+		want      models.Target
+		wantErr   bool
+	}{
+		{"origin of the criteria", &models.Task{StartPlanetID: 7}, &models.TargetCriteria{Origin: given}, inventory, *given, false},
+		{"queried start planet", &models.Task{StartPlanetID: 7, StartPlanet: stored}, &models.TargetCriteria{}, inventory, models.Target{Galaxy: 2, System: 40, Planet: 8}, false},
+		{"stored start planet when never queried", &models.Task{StartPlanetID: 7, StartPlanet: stored}, &models.TargetCriteria{}, nil, stored, false},
+		{"unknown start planet", &models.Task{StartPlanetID: 7}, &models.TargetCriteria{}, nil, models.Target{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := taskOrigin(tt.task, tt.criteria, tt.inventory)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("taskOrigin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.String() != tt.want.String() {
+				t.Errorf("taskOrigin() = %s, want %s", got.String(), tt.want.String())
+			}
+		})
+	}
+}
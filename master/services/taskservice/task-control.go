@@ -0,0 +1,232 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/config"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PauseTask stops the generator from dispatching a task, a run in flight still returns
+func (ts *taskService) PauseTask(ctx context.Context, taskID uint) (*models.Task, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] PauseTask", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
+
+	if _, serviceErr := ts.getAllowedTask(ctx, taskID, "write"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	var task *models.Task
+	err := ts.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if task, err = lockTask(tx, taskID); err != nil {
+			return err
+		}
+		// A run in flight can not be recalled, the task stays idle once it returns
+		if models.CanTransition(task.Status, models.TASK_STATUS_CANCELLED) {
+			if err := transitionTask(tx, task, models.TASK_STATUS_CANCELLED, "paused by user", "", nil); err != nil {
+				return err
+			}
+		}
+		return tx.Model(task).Update("enabled", false).Error
+	})
+	if err != nil {
+		log.Error("[TaskService] PauseTask", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Pause Task Error", err)
+	}
+	task.Enabled = false
+	ts.reindexTask(ctx, taskID)
+	log.Info("[TaskService] PauseTask Succeed", zap.String("traceID", traceID), zap.Uint("taskID", taskID))
+	return task, nil
+}
+
+// ResumeTask enables a task again and clears the failure state left by its retry
+// policy, a task waiting for ships is checked again on the next pass
+func (ts *taskService) ResumeTask(ctx context.Context, taskID uint) (*models.Task, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ResumeTask", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
+
+	if _, serviceErr := ts.getAllowedTask(ctx, taskID, "write"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	var task *models.Task
+	err := ts.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if task, err = lockTask(tx, taskID); err != nil {
+			return err
+		}
+		if task.Status == models.TASK_STATUS_CANCELLED || task.Status == models.TASK_STATUS_INSUFFICIENT_FLEET {
+			if err := transitionTask(tx, task, models.TASK_STATUS_READY, "resumed by user", "", nil); err != nil {
+				return err
+			}
+		}
+		return tx.Model(task).Updates(map[string]interface{}{
+			"enabled":              true,
+			"consecutive_failures": 0,
+			"retry_target_id":      0,
+			"disabled_reason":      "",
+		}).Error
+	})
+	if err != nil {
+		log.Error("[TaskService] ResumeTask", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Resume Task Error", err)
+	}
+	task.Enabled = true
+	task.ConsecutiveFailures = 0
+	task.RetryTargetID = 0
+	task.DisabledReason = ""
+	ts.reindexTask(ctx, taskID)
+	log.Info("[TaskService] ResumeTask Succeed", zap.String("traceID", traceID), zap.Uint("taskID", taskID))
+	return task, nil
+}
+
+// RunTaskNow dispatches a task through the instant queue, bypassing the
+// generator threshold and delay. It returns the uuid of the run.
+func (ts *taskService) RunTaskNow(ctx context.Context, taskID uint) (string, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] RunTaskNow", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
+
+	if _, serviceErr := ts.getAllowedTask(ctx, taskID, "write"); serviceErr != nil {
+		return "", serviceErr
+	}
+
+	tx := ts.DB.Begin()
+	if err := tx.Error; err != nil {
+		log.Error("[TaskService] RunTaskNow", zap.String("traceID", traceID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Begin Transaction Error", err)
+	}
+	locked, err := lockTask(tx, taskID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("[TaskService] RunTaskNow", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Lock Task Error", err)
+	}
+	var task models.Task
+	if err := tx.Preload("Targets").Preload("Fleet").Preload("FleetPreset").First(&task, taskID).Error; err != nil {
+		tx.Rollback()
+		log.Error("[TaskService] RunTaskNow", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Get Task Error", err)
+	}
+	switch {
+	case models.IsInFlight(task.Status):
+		tx.Rollback()
+		log.Warn("[TaskService] RunTaskNow task already running", zap.String("traceID", traceID), zap.Uint("taskID", taskID))
+		return "", utils.NewServiceError(http.StatusConflict, "Task Is Running", nil)
+	case task.Status == models.TASK_STATUS_COMPLETED:
+		tx.Rollback()
+		log.Warn("[TaskService] RunTaskNow task completed", zap.String("traceID", traceID), zap.Uint("taskID", taskID))
+		return "", utils.NewServiceError(http.StatusConflict, "Task Completed", nil)
+	case !models.IsDispatchable(task.Status):
+		tx.Rollback()
+		log.Warn("[TaskService] RunTaskNow task not ready", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.String("status", task.Status))
+		return "", utils.NewServiceError(http.StatusConflict, "Task Not Ready", nil)
+	}
+
+	var account models.Account
+	if err := tx.First(&account, task.AccountID).Error; err != nil {
+		tx.Rollback()
+		log.Error("[TaskService] RunTaskNow", zap.String("traceID", traceID), zap.Uint("accountID", task.AccountID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Get Account Error", err)
+	}
+
+	handler, err := getHandler(task.TaskType)
+	if err == nil {
+		err = handler.Validate(&task)
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Warn("[TaskService] RunTaskNow invalid task", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusBadRequest, "Invalid Task", err)
+	}
+	task.NextStart = time.Now().Unix()
+	singleTask, err := handler.BuildRequest(&task, &account)
+	if err != nil {
+		tx.Rollback()
+		log.Error("[TaskService] RunTaskNow", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusBadRequest, "Build Task Request Error", err)
+	}
+
+	if err := recordDispatch(tx, &task, singleTask); err != nil {
+		tx.Rollback()
+		log.Error("[TaskService] RunTaskNow", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Create Task Log Error", err)
+	}
+	if err := transitionTask(tx, locked, models.TASK_STATUS_QUEUED, "run now", singleTask.UUID, nil); err != nil {
+		tx.Rollback()
+		log.Error("[TaskService] RunTaskNow", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Update Task Error", err)
+	}
+
+	taskJSON, err := json.Marshal(singleTask)
+	if err != nil {
+		tx.Rollback()
+		log.Error("[TaskService] RunTaskNow failed to marshal task", zap.String("traceID", traceID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Marshal Task Error", err)
+	}
+	routingKey := config.INSTANT_QUEUE_NAME
+	if err := ts.MQ.SendNormalMessage(string(taskJSON), routingKey); err != nil {
+		tx.Rollback()
+		log.Error("[TaskService] RunTaskNow failed to publish task", zap.String("traceID", traceID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Publish Task Error", err)
+	}
+	if err := transitionTask(tx, locked, models.TASK_STATUS_DISPATCHED, "published", singleTask.UUID, nil); err != nil {
+		tx.Rollback()
+		log.Error("[TaskService] RunTaskNow", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Update Task Error", err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		log.Error("[TaskService] RunTaskNow failed to commit", zap.String("traceID", traceID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Commit Transaction Error", err)
+	}
+	ts.reindexTask(ctx, taskID)
+
+	log.Info("[TaskService] RunTaskNow task published", zap.String("traceID", traceID), zap.Uint("taskID", taskID),
+		zap.String("uuid", singleTask.UUID), zap.String("routingKey", routingKey))
+	return singleTask.UUID, nil
+}
+
+// getAllowedTask loads a task and checks that the user in ctx may act on the account owning it
+func (ts *taskService) getAllowedTask(ctx context.Context, taskID uint, act string) (*models.Task, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	var task models.Task
+	if err := ts.DB.First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn("[TaskService] task not found", zap.String("traceID", traceID), zap.Uint("taskID", taskID))
+			return nil, utils.NewServiceError(http.StatusNotFound, "Task Not Found", err)
+		}
+		log.Error("[TaskService] failed to get task", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Task Error", err)
+	}
+	if serviceErr := ts.checkAccountPermission(ctx, task.AccountID, act); serviceErr != nil {
+		return nil, serviceErr
+	}
+	return &task, nil
+}
+
+// checkAccountPermission checks that the user in ctx may act on an account
+func (ts *taskService) checkAccountPermission(ctx context.Context, accountID uint, act string) *utils.ServiceError {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	obj := models.Account{}.GetEntityPrefix() + strconv.Itoa(int(accountID))
+	allowed, err := ts.Enforcer.Enforce(ctx, strconv.Itoa(int(userID)), obj, act)
+	if err != nil {
+		log.Error("[TaskService] Casbin Enforce Error", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		return utils.NewServiceError(http.StatusInternalServerError, "Casbin Enforce Error", err)
+	}
+	if !allowed {
+		log.Warn("[TaskService] Permission Denied", zap.String("traceID", traceID), zap.Uint("userID", userID),
+			zap.Uint("accountID", accountID), zap.String("act", act))
+		return utils.NewServiceError(http.StatusForbidden, "Permission Denied", nil)
+	}
+	return nil
+}
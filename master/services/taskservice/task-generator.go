@@ -8,14 +8,17 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func (ts *taskService) GenerateAllTask() {
 	var accounts []*models.Account
 
-	if err := ts.DB.Preload("Tasks").
+	if err := ts.DB.Preload("Tasks", "status IS NULL OR status <> ?", models.TASK_STATUS_COMPLETED).
 		Preload("Tasks.Targets"). // 通过 Tasks 预加载 Targets
 		Preload("Tasks.Fleet").
+		Preload("Tasks.FleetPreset").
 		Where("expire_at > ?", time.Now()).
 		Find(&accounts).Error; err != nil {
 		log.Error("[TaskService::GenerateTask] failed to fetch accounts", zap.Error(err))
@@ -35,17 +38,7 @@ func (ts *taskService) GenerateAllTask() {
 
 func (ts *taskService) GenerateSingleTask(task *models.Task, account *models.Account) *models.SingleTaskRequest {
 	nextStart := time.Unix(task.NextStart, 0)
-	if !task.Enabled ||
-		task.Status != models.TaskStatusMap[models.TASK_STATUS_READY] ||
-		time.Until(nextStart) > config.QUEUE_THRESHOLD { // 如果距离执行时间超过1小时
-		reason := "unknown"
-		if !task.Enabled {
-			reason = "task disabled"
-		} else if task.Status != models.TaskStatusMap[models.TASK_STATUS_READY] {
-			reason = "task not in ready status"
-		} else if time.Until(nextStart) > config.QUEUE_THRESHOLD {
-			reason = "too early to generate"
-		}
+	if reason := skipReason(task, time.Now()); reason != "" {
 		log.Info("[TaskService::GenerateSingleTask] task not ready",
 			zap.String("task", task.Name),
 			zap.Uint("task_id", task.ID),
@@ -61,8 +54,18 @@ func (ts *taskService) GenerateSingleTask(task *models.Task, account *models.Acc
 		zap.Time("next_start", nextStart),
 		zap.Time("now", time.Now()))
 
+	handler, err := getHandler(task.TaskType)
+	if err != nil {
+		log.Error("[TaskService::GenerateSingleTask] unknown task type", zap.Uint("task_id", task.ID), zap.Error(err))
+		return nil
+	}
+	if err := handler.Validate(task); err != nil {
+		log.Error("[TaskService::GenerateSingleTask] invalid task", zap.Uint("task_id", task.ID), zap.Error(err))
+		return nil
+	}
+
 	// Generate single task request without DB operations
-	singleTask, err := task.ToSingleTaskRequest(account)
+	singleTask, err := handler.BuildRequest(task, account)
 	if err != nil {
 		log.Error("[TaskService::GenerateSingleTask] failed to convert task to single task", zap.Error(err))
 		return nil
@@ -71,26 +74,35 @@ func (ts *taskService) GenerateSingleTask(task *models.Task, account *models.Acc
 	return singleTask
 }
 
-func (ts *taskService) GenerateTaskForAccount(account *models.Account) error {
-	fourHoursAgo := time.Now().Add(-4 * time.Hour)
-
-	for _, task := range account.Tasks {
-		// Reset long-running tasks to ready status
-		if task.Status == models.TaskStatusMap[models.TASK_STATUS_RUNNING] &&
-			time.Unix(task.NextStart, 0).Before(fourHoursAgo) {
-			log.Warn("[TaskService::GenerateTaskForAccount] task stuck in running state, resetting to ready",
-				zap.Uint("task_id", task.ID),
-				zap.String("task_name", task.Name),
-				zap.Time("next_start", time.Unix(task.NextStart, 0)))
+// skipReason returns why the generator leaves a task alone, or "" when it is due
+func skipReason(task *models.Task, now time.Time) string {
+	if !task.Enabled {
+		return "task disabled"
+	}
+	if task.Status == models.TASK_STATUS_INSUFFICIENT_FLEET {
+		return "insufficient fleet"
+	}
+	if !models.IsDispatchable(task.Status) {
+		return "task not in ready status"
+	}
+	if time.Unix(task.NextStart, 0).Sub(now) > config.QUEUE_THRESHOLD { // 如果距离执行时间超过1小时
+		return "too early to generate"
+	}
+	return ""
+}
 
-			if err := ts.DB.Model(&task).Update("status", models.TaskStatusMap[models.TASK_STATUS_READY]).Error; err != nil {
-				log.Error("[TaskService::GenerateTaskForAccount] failed to reset task status",
-					zap.Error(err))
-				continue
-			}
-			task.Status = models.TaskStatusMap[models.TASK_STATUS_READY]
-		}
+// dispatchDelay returns the delay of the message of a task starting at nextStart
+func dispatchDelay(nextStart int64, now time.Time) time.Duration {
+	delay := time.Unix(nextStart, 0).Sub(now)
+	if delay < 0 {
+		delay = time.Duration(config.TASK_DELAY) * time.Second
+	}
+	return delay
+}
 
+func (ts *taskService) GenerateTaskForAccount(account *models.Account) error {
+	var inventory map[uint]models.FleetInventory
+	for _, task := range account.Tasks {
 		// Check and reset NextIndex if it's invalid
 		if task.NextIndex >= len(task.Targets) {
 			log.Warn("[TaskService::GenerateTaskForAccount] invalid next_index, resetting to 0",
@@ -105,34 +117,69 @@ func (ts *taskService) GenerateTaskForAccount(account *models.Account) error {
 			}
 		}
 
+		// Push next_start forward into the launch time allowed by the schedule
+		if task.Enabled && models.IsDispatchable(task.Status) {
+			if nextStart := scheduledStart(&task, time.Now()); nextStart != task.NextStart {
+				log.Info("[TaskService::GenerateTaskForAccount] next_start moved by schedule",
+					zap.Uint("task_id", task.ID),
+					zap.String("schedule_type", task.Schedule.Type),
+					zap.Time("old_next_start", time.Unix(task.NextStart, 0)),
+					zap.Time("new_next_start", time.Unix(nextStart, 0)))
+				if err := ts.DB.Model(&task).Update("next_start", nextStart).Error; err != nil {
+					log.Error("[TaskService::GenerateTaskForAccount] failed to update next_start",
+						zap.Error(err))
+					continue
+				}
+				task.NextStart = nextStart
+			}
+		}
+
 		if singleTask := ts.GenerateSingleTask(&task, account); singleTask != nil {
+			// Check the ships against the last known inventory of the start planet
+			if inventory == nil {
+				var err error
+				if inventory, err = loadFleetInventory(ts.DB, account.ID); err != nil {
+					return fmt.Errorf("failed to load fleet inventory: %v", err)
+				}
+			}
+			if reason := fleetShortage(&task, inventory); reason != "" {
+				log.Warn("[TaskService::GenerateTaskForAccount] fleet does not fit the start planet",
+					zap.Uint("task_id", task.ID),
+					zap.Uint("start_planet_id", task.StartPlanetID),
+					zap.String("reason", reason))
+				if err := ts.transitionTaskByID(task.ID, models.TASK_STATUS_INSUFFICIENT_FLEET, reason, ""); err != nil {
+					log.Error("[TaskService::GenerateTaskForAccount] failed to mark insufficient fleet", zap.Error(err))
+				}
+				continue
+			}
+
 			// Start transaction
 			tx := ts.DB.Begin()
 			if err := tx.Error; err != nil {
 				return fmt.Errorf("failed to begin transaction: %v", err)
 			}
 
-			// Lock task record
-			if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&models.Task{}, task.ID).Error; err != nil {
+			// Lock task record, skip it if it was started meanwhile
+			locked, err := lockTask(tx, task.ID)
+			if err != nil {
 				tx.Rollback()
 				return fmt.Errorf("failed to lock task: %v", err)
 			}
-
-			// Create task log
-			taskLog := models.TaskLog{
-				TaskID: task.ID,
-				UUID:   singleTask.UUID,
-				Status: models.TASK_RESULT_RUNNING,
-			}
-			if err := tx.Create(&taskLog).Error; err != nil {
+			if !models.IsDispatchable(locked.Status) {
 				tx.Rollback()
-				return fmt.Errorf("failed to create task log: %v", err)
+				log.Info("[TaskService::GenerateTaskForAccount] task started meanwhile, skipping",
+					zap.Uint("task_id", task.ID),
+					zap.String("status", locked.Status))
+				continue
 			}
 
-			// Update task's NextIndex
-			if err := tx.Model(&task).Update("next_index", task.NextIndex).Error; err != nil {
+			if err := recordDispatch(tx, &task, singleTask); err != nil {
+				tx.Rollback()
+				return err
+			}
+			if err := transitionTask(tx, locked, models.TASK_STATUS_QUEUED, "generated", singleTask.UUID, nil); err != nil {
 				tx.Rollback()
-				return fmt.Errorf("failed to update next_index: %v", err)
+				return fmt.Errorf("failed to queue task: %v", err)
 			}
 
 			// Commit transaction
@@ -141,16 +188,12 @@ func (ts *taskService) GenerateTaskForAccount(account *models.Account) error {
 			}
 
 			// Convert to JSON and send message
-			nextStart := time.Unix(singleTask.NextStart, 0)
 			taskJson, err := json.Marshal(singleTask)
 			if err != nil {
 				return fmt.Errorf("failed to marshal task: %v", err)
 			}
 
-			delay := time.Until(nextStart)
-			if delay < 0 {
-				delay = time.Duration(config.TASK_DELAY) * time.Second
-			}
+			delay := dispatchDelay(singleTask.NextStart, time.Now())
 			log.Debug("[TaskService::GenerateTaskForAccount] delay",
 				zap.Int64("delay", delay.Milliseconds()),
 				zap.String("task", string(taskJson)))
@@ -158,19 +201,103 @@ func (ts *taskService) GenerateTaskForAccount(account *models.Account) error {
 			// Send delayed message
 			routingKey := config.TASK_QUEUE_NAME
 			if err := ts.MQ.SendDelayedMessage(string(taskJson), routingKey, delay); err != nil {
+				if resetErr := ts.abandonDispatch(task.ID, singleTask.UUID, err); resetErr != nil {
+					log.Error("[TaskService::GenerateTaskForAccount] failed to reset task status", zap.Error(resetErr))
+				}
 				return fmt.Errorf("failed to send delayed message: %v", err)
 			}
 
 			// Update task status
-			task.Status = models.TaskStatusMap[models.TASK_STATUS_RUNNING]
-			if err := ts.DB.Save(&task).Error; err != nil {
-				return fmt.Errorf("failed to save task: %v", err)
+			if err := ts.markDispatched(task.ID, singleTask.UUID); err != nil {
+				return fmt.Errorf("failed to update task status: %v", err)
 			}
 		}
 	}
 	return nil
 }
 
+// lockTask loads a task with a row lock held until tx ends
+func lockTask(tx *gorm.DB, taskID uint) (*models.Task, error) {
+	var task models.Task
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, taskID).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// recordDispatch creates the running task log of a request and saves the
+// target selection state it consumed
+func recordDispatch(tx *gorm.DB, task *models.Task, singleTask *models.SingleTaskRequest) error {
+	launch := time.Now().Add(dispatchDelay(singleTask.NextStart, time.Now())).Unix()
+	taskLog := models.TaskLog{
+		TaskID:       task.ID,
+		AccountID:    task.AccountID,
+		TaskType:     task.TaskType,
+		UUID:         singleTask.UUID,
+		Status:       models.TASK_RESULT_RUNNING,
+		TargetID:     singleTask.Target.ID,
+		Deadline:     runDeadline(task.TaskType, launch),
+		DispatchedAt: launch,
+	}
+	if err := tx.Create(&taskLog).Error; err != nil {
+		return fmt.Errorf("failed to create task log: %v", err)
+	}
+
+	// Update task's NextIndex
+	if err := tx.Model(task).Update("next_index", task.NextIndex).Error; err != nil {
+		return fmt.Errorf("failed to update next_index: %v", err)
+	}
+
+	// The least recent strategy orders targets by their last attempt, failed ones included
+	if singleTask.Target.ID != 0 {
+		if err := tx.Model(&models.Target{}).Where("id = ?", singleTask.Target.ID).
+			Update("last_attempt_at", launch).Error; err != nil {
+			return fmt.Errorf("failed to update last_attempt_at: %v", err)
+		}
+	}
+
+	// Persist the random strategy cycle
+	if task.TargetStrategy == models.TARGET_STRATEGY_RANDOM {
+		if err := savePickedTargets(tx, task); err != nil {
+			return fmt.Errorf("failed to update picked targets: %v", err)
+		}
+	}
+	return nil
+}
+
+func savePickedTargets(tx *gorm.DB, task *models.Task) error {
+	var picked []uint
+	for _, target := range task.Targets {
+		if target.Picked {
+			picked = append(picked, target.ID)
+		}
+	}
+	if len(picked) == 0 {
+		return tx.Model(&models.Target{}).Where("task_id = ?", task.ID).Update("picked", false).Error
+	}
+	return tx.Model(&models.Target{}).Where("task_id = ?", task.ID).
+		Update("picked", gorm.Expr("id IN ?", picked)).Error
+}
+
+// scheduledStart returns the earliest launch time allowed by the task schedule,
+// never earlier than the stored next_start nor than now.
+func scheduledStart(task *models.Task, now time.Time) int64 {
+	if !task.Schedule.Constrained() {
+		return task.NextStart
+	}
+	start := time.Unix(task.NextStart, 0)
+	if start.Before(now) {
+		start = now
+	}
+	aligned := task.Schedule.Align(start)
+	if aligned.Equal(start) {
+		return task.NextStart
+	}
+	return aligned.Unix()
+}
+
+// GenerateTaskLoop polls every account, it is used when Redis is not available
+// and there is neither a leader lease nor a schedule index, see ScheduleLoop
 func (ts *taskService) GenerateTaskLoop() {
 	time.Sleep(5 * time.Second)
 	log.Info("[TaskService::GenerateTaskLoop] start task generator loop")
@@ -85,7 +85,7 @@ func Test_taskService_GenerateSingleTask(t *testing.T) {
 		Enabled:   true,
 		AccountID: 1,
 		TaskType:  1,
-		Status:    models.TaskStatusMap[models.TASK_STATUS_RUNNING],
+		Status:    models.TASK_STATUS_RUNNING,
 		Targets: []models.Target{
 			{
 				Galaxy: 1,
@@ -0,0 +1,88 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/config"
+	"GalaxyEmpireWeb/models"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaskHandler is the master side of one task type.
+// Adding a mission type means implementing this interface and registering it,
+// the shared generator and result transaction stay untouched.
+type TaskHandler interface {
+	// Validate checks a task before it is saved or dispatched
+	Validate(task *models.Task) error
+	// BuildRequest turns a stored task into the message sent to the node
+	BuildRequest(task *models.Task, account *models.Account) (*models.SingleTaskRequest, error)
+	// HandleResult applies a node result inside the shared result transaction,
+	// the task log has already been updated when it is called
+	HandleResult(tx *gorm.DB, response *models.SingleTaskResponse) (*models.Task, error)
+	// RetryPolicy is the default retry policy of this type, tasks may override its fields
+	RetryPolicy() models.RetryPolicy
+	// Timeout is how long after its launch a run may stay without result
+	Timeout() time.Duration
+}
+
+// ReportHandler is implemented by the handlers of missions whose report is
+// written after the fleets arrive. The node sends the result of the run at
+// launch and the report later, as a TASK_RESULT_REPORT result of the same run.
+type ReportHandler interface {
+	// HandleReport stores the report of a run, a report stored before is kept
+	HandleReport(tx *gorm.DB, response *models.SingleTaskResponse) error
+}
+
+// defaultRetryPolicy backs off exponentially and stops a task after about a day of failures
+var defaultRetryPolicy = models.RetryPolicy{
+	BackoffBase:  config.FAILED_TASK_DELAY,
+	BackoffMax:   config.FAILED_TASK_BACKOFF_MAX,
+	DisableAfter: config.FAILED_TASK_DISABLE_AFTER,
+}
+
+var (
+	handlers   = map[int]TaskHandler{}
+	handlersMu sync.RWMutex
+)
+
+// RegisterHandler registers the handler of a task type, replacing any previous one
+func RegisterHandler(taskType int, handler TaskHandler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[taskType] = handler
+}
+
+var errUnknownTaskType = errors.New("no handler registered for task type")
+
+func getHandler(taskType int) (TaskHandler, error) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	handler, ok := handlers[taskType]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", errUnknownTaskType, taskType)
+	}
+	return handler, nil
+}
+
+// runDeadline returns the deadline of a run of taskType launched at start
+func runDeadline(taskType int, start int64) int64 {
+	timeout := config.FLEET_TASK_TIMEOUT
+	if handler, err := getHandler(taskType); err == nil {
+		timeout = handler.Timeout()
+	}
+	return start + int64(timeout/time.Second)
+}
+
+func init() {
+	RegisterHandler(models.TASKTYPE_ATTACK, &attackHandler{})
+	RegisterHandler(models.TASKTYPE_EXPLORE, &exploreHandler{})
+	RegisterHandler(models.TASKTYPE_TRANSPORT, &transportHandler{})
+	RegisterHandler(models.TASKTYPE_SPY, &spyHandler{})
+	RegisterHandler(models.TASKTYPE_LOGIN, &instantHandler{name: "login"})
+	RegisterHandler(models.TASKTYPE_QUERY_PLANET_ID, &instantHandler{name: "query planet id"})
+	RegisterHandler(models.TASKTYPE_QUERY_FLEET, &fleetQueryHandler{instantHandler{name: "query fleet"}})
+	RegisterHandler(models.TASKTYPE_SCAN_GALAXY, &galaxyScanHandler{instantHandler{name: "galaxy scan"}})
+}
@@ -6,6 +6,7 @@ import (
 	"GalaxyEmpireWeb/utils"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -19,9 +20,13 @@ func (ts *taskService) CheckAccountLogin(ctx context.Context, account *models.Ac
 	tx := ts.DB.Begin()
 	log.Info("[TaskService::CheckAccouuntLogin] start to check account login", zap.String("uuid", uuid))
 	taskLog := models.TaskLog{
-		TaskID: 0, // Not in DB
-		UUID:   uuid,
-		Status: models.TASK_STATUS_READY,
+		TaskID:       0, // Not in DB
+		AccountID:    account.ID,
+		TaskType:     models.TASKTYPE_LOGIN,
+		UUID:         uuid,
+		Status:       models.TASK_RESULT_RUNNING,
+		Deadline:     runDeadline(models.TASKTYPE_LOGIN, time.Now().Unix()),
+		DispatchedAt: time.Now().Unix(),
 	}
 	if err1 := tx.Create(&taskLog).Error; err1 != nil {
 		log.Error("[TaskService::CheckAccouuntLogin] failed to create task log", zap.Error(err1))
@@ -64,8 +69,8 @@ func (ts *taskService) GetLoginInfo(ctx context.Context, uuid string) bool {
 			continue
 
 		}
-		if taskLog.Status == models.TASK_RESULT_FAILED {
-			log.Warn("[TaskService::GetLoginInfo] login failed", zap.String("uuid", uuid))
+		if taskLog.Status == models.TASK_RESULT_FAILED || taskLog.Status == models.TASK_RESULT_TIMEOUT {
+			log.Warn("[TaskService::GetLoginInfo] login failed", zap.String("uuid", uuid), zap.Int("status", taskLog.Status))
 			return false
 		}
 		if taskLog.Status == models.TASK_RESULT_SUCCESS {
@@ -86,9 +91,13 @@ func (ts *taskService) QueryPlanetID(ctx context.Context, target *models.Target,
 	tx := ts.DB.Begin()
 	log.Info("[TaskService::QueryPlanetID] start to query planet id", zap.String("uuid", uuid), zap.String("target", target.String()), zap.String("traceID", utils.TraceIDFromContext(ctx)))
 	taskLog := models.TaskLog{
-		TaskID: 0, // Not in DB
-		UUID:   uuid,
-		Status: models.TASK_STATUS_READY,
+		TaskID:       0, // Not in DB
+		AccountID:    account.ID,
+		TaskType:     models.TASKTYPE_QUERY_PLANET_ID,
+		UUID:         uuid,
+		Status:       models.TASK_RESULT_RUNNING,
+		Deadline:     runDeadline(models.TASKTYPE_QUERY_PLANET_ID, time.Now().Unix()),
+		DispatchedAt: time.Now().Unix(),
 	}
 	if err1 := tx.Create(&taskLog).Error; err1 != nil {
 		log.Error("[TaskService::QueryPlanetID] failed to create task log", zap.Error(err1))
@@ -127,8 +136,8 @@ func (ts *taskService) GetPlanetID(ctx context.Context, uuid string) (int, *util
 	}
 
 	// Check task status first
-	if taskLog.Status == models.TASK_RESULT_FAILED {
-		log.Warn("[TaskService::GetPlanetID] query failed", zap.String("uuid", uuid))
+	if taskLog.Status == models.TASK_RESULT_FAILED || taskLog.Status == models.TASK_RESULT_TIMEOUT {
+		log.Warn("[TaskService::GetPlanetID] query failed", zap.String("uuid", uuid), zap.Int("status", taskLog.Status))
 		return 0, utils.NewServiceError(http.StatusInternalServerError, "Query Planet ID Failed", nil)
 	}
 
@@ -155,3 +164,45 @@ func (ts *taskService) GetPlanetID(ctx context.Context, uuid string) (int, *util
 	}
 	return planetIDInt, nil
 }
+
+// dispatchInstant records the running log of an instant request of an account
+// and publishes the request once the log is committed, so the result always
+// finds its log. A request that can not be published fails its log.
+func (ts *taskService) dispatchInstant(ctx context.Context, account *models.Account, request *models.SingleTaskRequest) (string, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	now := time.Now().Unix()
+	request.UUID = uuid.New().String()
+	request.Account = *account.ToInfo()
+	request.NextStart = now
+	taskJSON, err := json.Marshal(request)
+	if err != nil {
+		log.Error("[TaskService] dispatchInstant failed to marshal task", zap.String("traceID", traceID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Marshal Task Error", err)
+	}
+	taskLog := models.TaskLog{
+		AccountID:    account.ID,
+		TaskType:     request.TaskType,
+		UUID:         request.UUID,
+		Status:       models.TASK_RESULT_RUNNING,
+		Deadline:     runDeadline(request.TaskType, now),
+		DispatchedAt: now,
+	}
+	if err := ts.DB.Create(&taskLog).Error; err != nil {
+		log.Error("[TaskService] dispatchInstant failed to create task log", zap.String("traceID", traceID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Create Task Log Error", err)
+	}
+	if err := ts.MQ.SendNormalMessage(string(taskJSON), config.INSTANT_QUEUE_NAME); err != nil {
+		log.Error("[TaskService] dispatchInstant failed to publish task", zap.String("traceID", traceID),
+			zap.Uint("accountID", account.ID), zap.String("uuid", request.UUID), zap.Error(err))
+		if err := ts.DB.Model(&taskLog).Updates(map[string]interface{}{
+			"status":  models.TASK_RESULT_FAILED,
+			"err_msg": fmt.Sprintf("publish failed: %v", err),
+		}).Error; err != nil {
+			log.Error("[TaskService] dispatchInstant failed to fail task log", zap.String("traceID", traceID), zap.Error(err))
+		}
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Publish Task Error", err)
+	}
+	log.Info("[TaskService] dispatchInstant task published", zap.String("traceID", traceID),
+		zap.Uint("accountID", account.ID), zap.Int("taskType", request.TaskType), zap.String("uuid", request.UUID))
+	return request.UUID, nil
+}
@@ -0,0 +1,114 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ListTaskLogs returns a page of the runs of a task and the number of runs matching the filter
+func (ts *taskService) ListTaskLogs(ctx context.Context, taskID uint, filter *models.TaskLogFilter) ([]models.TaskLog, int64, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ListTaskLogs", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
+
+	if _, serviceErr := ts.getAllowedTask(ctx, taskID, "read"); serviceErr != nil {
+		return nil, 0, serviceErr
+	}
+	return ts.listTaskLogs(ctx, ts.DB.Where("task_id = ?", taskID), filter)
+}
+
+// ListAccountLogs returns a page of the runs of an account, instant requests included
+func (ts *taskService) ListAccountLogs(ctx context.Context, accountID uint, filter *models.TaskLogFilter) ([]models.TaskLog, int64, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ListAccountLogs", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("accountID", accountID))
+
+	if serviceErr := ts.checkAccountPermission(ctx, accountID, "read"); serviceErr != nil {
+		return nil, 0, serviceErr
+	}
+	return ts.listTaskLogs(ctx, ts.accountLogScope(accountID), filter)
+}
+
+// accountLogScope matches the logs of an account. Older logs have no account,
+// they are found through their task.
+func (ts *taskService) accountLogScope(accountID uint) *gorm.DB {
+	tasks := ts.DB.Model(&models.Task{}).Unscoped().Select("id").Where("account_id = ?", accountID)
+	return ts.DB.Where("account_id = ? OR (account_id = 0 AND task_id IN (?))", accountID, tasks)
+}
+
+func (ts *taskService) listTaskLogs(ctx context.Context, scope *gorm.DB, filter *models.TaskLogFilter) ([]models.TaskLog, int64, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	query := ts.DB.Model(&models.TaskLog{}).Where(scope)
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.TaskType != 0 {
+		query = query.Where("task_type = ?", filter.TaskType)
+	}
+	if filter.Since != 0 {
+		query = query.Where("created_at >= ?", time.Unix(filter.Since, 0))
+	}
+	if filter.Until != 0 {
+		query = query.Where("created_at < ?", time.Unix(filter.Until, 0))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Error("[TaskService] listTaskLogs", zap.String("traceID", traceID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Count Task Log Error", err)
+	}
+	order := "created_at DESC, id DESC"
+	if filter.Asc {
+		order = "created_at, id"
+	}
+	var logs []models.TaskLog
+	if err := query.Order(order).
+		Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&logs).Error; err != nil {
+		log.Error("[TaskService] listTaskLogs", zap.String("traceID", traceID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Get Task Log Error", err)
+	}
+	return logs, total, nil
+}
+
+// ListTransportRecords returns a page of the deliveries of a transport task and
+// their number. Status and task type of the filter do not apply.
+func (ts *taskService) ListTransportRecords(ctx context.Context, taskID uint, filter *models.TaskLogFilter) ([]models.TransportRecord, int64, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ListTransportRecords", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
+
+	if _, serviceErr := ts.getAllowedTask(ctx, taskID, "read"); serviceErr != nil {
+		return nil, 0, serviceErr
+	}
+	query := ts.DB.Model(&models.TransportRecord{}).Where("task_id = ?", taskID)
+	if filter.Since != 0 {
+		query = query.Where("created_at >= ?", time.Unix(filter.Since, 0))
+	}
+	if filter.Until != 0 {
+		query = query.Where("created_at < ?", time.Unix(filter.Until, 0))
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Error("[TaskService] ListTransportRecords", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Count Transport Record Error", err)
+	}
+	order := "created_at DESC, id DESC"
+	if filter.Asc {
+		order = "created_at, id"
+	}
+	var records []models.TransportRecord
+	if err := query.Order(order).
+		Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&records).Error; err != nil {
+		log.Error("[TaskService] ListTransportRecords", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Get Transport Record Error", err)
+	}
+	return records, total, nil
+}
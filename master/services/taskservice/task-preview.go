@@ -0,0 +1,108 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PreviewTasks runs the generator decisions on the tasks of the user in ctx
+// without writing to the DB or publishing anything. accountID 0 previews all
+// accounts of the user. Weighted and random strategies may pick another target
+// on the real pass.
+func (ts *taskService) PreviewTasks(ctx context.Context, accountID uint) ([]*models.TaskPreview, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] PreviewTasks", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("accountID", accountID))
+
+	query := ts.DB.Preload("Tasks").Preload("Tasks.Targets").Preload("Tasks.Fleet").Preload("Tasks.FleetPreset")
+	if accountID != 0 {
+		if serviceErr := ts.checkAccountPermission(ctx, accountID, "read"); serviceErr != nil {
+			return nil, serviceErr
+		}
+		query = query.Where("id = ?", accountID)
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+	var accounts []*models.Account
+	if err := query.Find(&accounts).Error; err != nil {
+		log.Error("[TaskService] PreviewTasks", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Account Error", err)
+	}
+
+	now := time.Now()
+	previews := []*models.TaskPreview{}
+	for _, account := range accounts {
+		inventory, err := loadFleetInventory(ts.DB, account.ID)
+		if err != nil {
+			log.Error("[TaskService] PreviewTasks", zap.String("traceID", traceID), zap.Uint("accountID", account.ID), zap.Error(err))
+			return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Fleet Inventory Error", err)
+		}
+		for i := range account.Tasks {
+			previews = append(previews, previewTask(&account.Tasks[i], account, inventory, now))
+		}
+	}
+	return previews, nil
+}
+
+// previewTask mirrors GenerateTaskForAccount and GenerateSingleTask on an in-memory task
+func previewTask(task *models.Task, account *models.Account, inventory map[uint]models.FleetInventory, now time.Time) *models.TaskPreview {
+	preview := &models.TaskPreview{
+		TaskID:    task.ID,
+		TaskName:  task.Name,
+		AccountID: account.ID,
+		NextStart: task.NextStart,
+	}
+	if !account.ExpireAt.After(now) {
+		preview.SkipReason = "account expired"
+		return preview
+	}
+	if task.Status == models.TASK_STATUS_COMPLETED {
+		preview.SkipReason = "task completed"
+		return preview
+	}
+
+	if task.NextIndex >= len(task.Targets) {
+		task.NextIndex = 0
+	}
+	if task.Enabled && models.IsDispatchable(task.Status) {
+		task.NextStart = scheduledStart(task, now)
+		preview.NextStart = task.NextStart
+	}
+
+	if reason := skipReason(task, now); reason != "" {
+		if reason == "task disabled" && task.DisabledReason != "" {
+			reason = fmt.Sprintf("%s: %s", reason, task.DisabledReason)
+		}
+		preview.SkipReason = reason
+		return preview
+	}
+	handler, err := getHandler(task.TaskType)
+	if err != nil {
+		preview.SkipReason = "unknown task type"
+		return preview
+	}
+	if err := handler.Validate(task); err != nil {
+		preview.SkipReason = fmt.Sprintf("invalid task: %v", err)
+		return preview
+	}
+	singleTask, err := handler.BuildRequest(task, account)
+	if err != nil {
+		preview.SkipReason = fmt.Sprintf("failed to convert task to single task: %v", err)
+		return preview
+	}
+	if reason := fleetShortage(task, inventory); reason != "" {
+		preview.SkipReason = reason
+		return preview
+	}
+
+	preview.WouldDispatch = true
+	preview.Target = &singleTask.Target
+	preview.DelaySeconds = int64(dispatchDelay(singleTask.NextStart, now) / time.Second)
+	return preview
+}
@@ -0,0 +1,69 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"testing"
+	"time"
+)
+
+func Test_previewTask(t *testing.T) {
+	now := time.Now()
+	account := &models.Account{ExpireAt: now.Add(24 * time.Hour)}
+	newTask := func() *models.Task {
+		return &models.Task{
+			Name:      "test",
+			NextStart: now.Unix(),
+			Enabled:   true,
+			TaskType:  models.TASKTYPE_ATTACK,
+			Status:    "ready",
+			Targets:   []models.Target{{Galaxy: 1, System: 1, Planet: 1}, {Galaxy: 1, System: 1, Planet: 2}},
+			Repeat:    1,
+			NextIndex: 1,
+			TargetNum: 2,
+		}
+	}
+
+	due := newTask()
+	disabled := newTask()
+	disabled.Enabled = false
+	disabled.DisabledReason = "failed 24 times in a row"
+	early := newTask()
+	early.NextStart = now.Add(2 * time.Hour).Unix()
+	running := newTask()
+	running.Status = "running"
+	running.NextStart = now.Add(-5 * time.Hour).Unix()
+	short := newTask()
+	short.StartPlanetID = 7
+	short.Fleet = models.Fleet{LightFighter: 20}
+	waiting := newTask()
+	waiting.Status = models.TASK_STATUS_INSUFFICIENT_FLEET
+	inventory := map[uint]models.FleetInventory{7: {PlanetID: 7, Ships: models.FleetDTO{LightFighter: 10}}}
+
+	tests := []struct {
+		name     string
+		task     *models.Task
+		account  *models.Account
+		dispatch bool
+		reason   string
+		planet   int
+	}{
+		{"due", due, account, true, "", 2},
+		{"disabled", disabled, account, false, "task disabled: failed 24 times in a row", 0},
+		{"too early", early, account, false, "too early to generate", 0},
+		{"running", running, account, false, "task not in ready status", 0},
+		{"insufficient fleet", short, account, false, "insufficient fleet: lf 20/10", 0},
+		{"waiting for fleet", waiting, account, false, "insufficient fleet", 0},
+		{"account expired", newTask(), &models.Account{ExpireAt: now.Add(-time.Hour)}, false, "account expired", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := previewTask(tt.task, tt.account, inventory, now)
+			if got.WouldDispatch != tt.dispatch || got.SkipReason != tt.reason {
+				t.Fatalf("previewTask() = %v %q, want %v %q", got.WouldDispatch, got.SkipReason, tt.dispatch, tt.reason)
+			}
+			if tt.dispatch && got.Target.Planet != tt.planet {
+				t.Errorf("previewTask() target planet = %d, want %d", got.Target.Planet, tt.planet)
+			}
+		})
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"GalaxyEmpireWeb/services/casbinservice"
 	"GalaxyEmpireWeb/utils"
 	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -22,6 +24,8 @@ type taskService struct {
 	DB       *gorm.DB
 	MQ       *queue.RabbitMQConnection
 	Enforcer casbinservice.Enforcer
+	RDB      *redis.Client
+	leader   *leaderElector // nil when RDB is nil
 }
 
 func GetService() *taskService {
@@ -30,18 +34,28 @@ func GetService() *taskService {
 	}
 	return taskServiceInstance
 }
-func InitService(db *gorm.DB, mq *queue.RabbitMQConnection, enforcer casbinservice.Enforcer) {
-	taskServiceInstance = NewService(db, mq, enforcer)
-	go taskServiceInstance.GenerateTaskLoop()
+func InitService(db *gorm.DB, rdb *redis.Client, mq *queue.RabbitMQConnection, enforcer casbinservice.Enforcer) {
+	taskServiceInstance = NewService(db, rdb, mq, enforcer)
+	if rdb != nil {
+		// Every replica serves HTTP and results, only the leader schedules
+		taskServiceInstance.leader = newLeaderElector(rdb, config.LEADER_LEASE_KEY, config.LEADER_LEASE_TTL)
+		go taskServiceInstance.leader.Run(context.Background())
+		go taskServiceInstance.ScheduleLoop()
+	} else {
+		go taskServiceInstance.GenerateTaskLoop()
+	}
+	go taskServiceInstance.ReapLoop()
 	go taskServiceInstance.ListenFromResultQueue(config.RESULT_QUEUE_NAME)
-	db.AutoMigrate(&models.Task{}, &models.TaskLog{})
+	go taskServiceInstance.ListenFromDeadLetterQueue(config.RESULT_DLQ_NAME)
+	db.AutoMigrate(&models.Task{}, &models.TaskLog{}, &models.TaskTransition{}, &models.DeadLetter{}, &models.TaskTemplate{}, &models.FleetPreset{}, &models.FleetInventory{}, &models.TransportRecord{}, &models.SpyReport{}, &models.BattleReport{}, &models.ExpeditionReport{}, &models.UniversePlanet{})
 }
 
-func NewService(db *gorm.DB, mq *queue.RabbitMQConnection, enforcer casbinservice.Enforcer) *taskService {
+func NewService(db *gorm.DB, rdb *redis.Client, mq *queue.RabbitMQConnection, enforcer casbinservice.Enforcer) *taskService {
 	return &taskService{
 		DB:       db,
 		MQ:       mq,
 		Enforcer: enforcer,
+		RDB:      rdb,
 	}
 }
 
@@ -49,9 +63,20 @@ func (ts *taskService) AddTask(ctx context.Context, task *models.Task) *utils.Se
 	traceID := utils.TraceIDFromContext(ctx)
 	userID := utils.UserIDFromContext(ctx)
 	log.Info("[TaskService] AddTask", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Any("task", task), zap.Int("AccountID", int(task.AccountID)))
+	if serviceErr := ts.checkFleetPreset(ctx, task); serviceErr != nil {
+		return serviceErr
+	}
+	if err := validateTask(task); err != nil {
+		log.Warn("[TaskService] AddTask invalid task", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		return utils.NewServiceError(http.StatusBadRequest, "Invalid Task", err)
+	}
+	if serviceErr := ts.checkAccountPlanets(ctx, ts.DB, task, true); serviceErr != nil {
+		return serviceErr
+	}
+	task.Status = models.TASK_STATUS_READY // every task starts at the beginning of the state machine
 
 	tx := ts.DB.Begin()
-	if err := tx.Create(task).Error; err != nil {
+	if err := tx.Omit("FleetPreset").Create(task).Error; err != nil {
 		log.Error("[TaskService] AddTask", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
 		return utils.NewServiceError(http.StatusInternalServerError, "Create Task Error", err)
 	}
@@ -79,6 +104,7 @@ func (ts *taskService) AddTask(ctx context.Context, task *models.Task) *utils.Se
 	}
 
 	go ts.Enforcer.ReloadPolicy()
+	ts.reindexTask(ctx, task.ID)
 
 	return nil
 }
@@ -124,10 +150,22 @@ func (ts *taskService) GetTaskByID(ctx context.Context, taskID uint) (*models.Ta
 	}
 	return &task, nil
 }
+
+// serverTaskColumns are kept by the dispatch of a task, an update from a client
+// must not write back the values it read
+var serverTaskColumns = []string{"run_count", "consecutive_failures", "retry_target_id", "next_index", "disabled_reason"}
+
 func (ts *taskService) UpdateTask(ctx context.Context, task *models.Task) *utils.ServiceError {
 	traceID := utils.TraceIDFromContext(ctx)
 	userID := utils.UserIDFromContext(ctx)
 	log.Info("[TaskService] UpdateTask", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Any("task", task), zap.Int("AccountID", int(task.AccountID)))
+	if serviceErr := ts.checkFleetPreset(ctx, task); serviceErr != nil {
+		return serviceErr
+	}
+	if err := validateTask(task); err != nil {
+		log.Warn("[TaskService] UpdateTask invalid task", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		return utils.NewServiceError(http.StatusBadRequest, "Invalid Task", err)
+	}
 	allowed, err := ts.Enforcer.Enforce(ctx, strconv.Itoa(int(task.AccountID)), task.GetEntityPrefix()+strconv.Itoa(int(task.ID)), "write")
 	if err != nil {
 		log.Error("[TaskService] UpdateTask", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
@@ -139,7 +177,32 @@ func (ts *taskService) UpdateTask(ctx context.Context, task *models.Task) *utils
 	}
 
 	tx := ts.DB.Begin()
-	if err := tx.Save(task).Error; err != nil {
+	// The status only changes through transitions, enabled toggles pause and resume
+	current, err := lockTask(tx, task.ID)
+	if err != nil {
+		log.Error("[TaskService] UpdateTask", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		tx.Rollback()
+		return utils.NewServiceError(http.StatusInternalServerError, "Update Task Error", err)
+	}
+	if serviceErr := ts.checkAccountPlanets(ctx, tx, task, task.StartPlanetID != current.StartPlanetID); serviceErr != nil {
+		tx.Rollback()
+		return serviceErr
+	}
+	if task.Enabled && current.Status == models.TASK_STATUS_CANCELLED {
+		err = transitionTask(tx, current, models.TASK_STATUS_READY, "enabled by update", "", nil)
+	} else if task.Enabled && current.Status == models.TASK_STATUS_INSUFFICIENT_FLEET {
+		// The fleet may fit now, the generator checks it again
+		err = transitionTask(tx, current, models.TASK_STATUS_READY, "updated", "", nil)
+	} else if !task.Enabled && models.CanTransition(current.Status, models.TASK_STATUS_CANCELLED) {
+		err = transitionTask(tx, current, models.TASK_STATUS_CANCELLED, "disabled by update", "", nil)
+	}
+	if err != nil {
+		log.Error("[TaskService] UpdateTask", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		tx.Rollback()
+		return utils.NewServiceError(http.StatusInternalServerError, "Update Task Error", err)
+	}
+	task.Status = current.Status
+	if err := tx.Omit(append(serverTaskColumns, "FleetPreset")...).Save(task).Error; err != nil {
 		log.Error("[TaskService] UpdateTask", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
 		tx.Rollback()
 		return utils.NewServiceError(http.StatusInternalServerError, "Update Task Error", err)
@@ -147,6 +210,7 @@ func (ts *taskService) UpdateTask(ctx context.Context, task *models.Task) *utils
 	log.Info("[TaskService] UpdateTask Succeed", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Any("task", task), zap.Int("AccountID", int(task.AccountID)))
 
 	tx.Commit()
+	ts.reindexTask(ctx, task.ID)
 	return nil
 }
 func (ts *taskService) DeleteTask(ctx context.Context, taskID uint) *utils.ServiceError {
@@ -173,7 +237,38 @@ func (ts *taskService) DeleteTask(ctx context.Context, taskID uint) *utils.Servi
 		return utils.NewServiceError(http.StatusNotFound, "Task Not Found", nil)
 	}
 
+	ts.reindexTask(ctx, taskID)
 	log.Info("[TaskService] DeleteTask Succeed", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
 	return nil
 
 }
+
+// validateTask runs the checks shared by every task type and those of its handler
+func validateTask(task *models.Task) error {
+	if err := task.Schedule.Validate(); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	if err := task.ValidateRunLimit(); err != nil {
+		return err
+	}
+	if err := models.ValidateTargetStrategy(task.TargetStrategy); err != nil {
+		return err
+	}
+	if err := task.Cargo.Validate(); err != nil {
+		return err
+	}
+	for _, target := range task.Targets {
+		if target.Weight < 0 {
+			return fmt.Errorf("target %s has a negative weight", target.String())
+		}
+	}
+	handler, err := getHandler(task.TaskType)
+	if err != nil {
+		return err
+	}
+	// Zero fields take the defaults of the type, check the policy the runs will use
+	if err := task.Retry.WithDefaults(handler.RetryPolicy()).Validate(); err != nil {
+		return err
+	}
+	return handler.Validate(task)
+}
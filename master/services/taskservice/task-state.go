@@ -0,0 +1,113 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// transitionTask moves a locked task to status to inside tx and records the
+// change. updates are saved along with the status. Illegal transitions are
+// rejected with a *models.IllegalTransitionError.
+func transitionTask(tx *gorm.DB, task *models.Task, to, reason, uuid string, updates map[string]interface{}) error {
+	from := task.Status
+	if !models.CanTransition(from, to) {
+		return &models.IllegalTransitionError{TaskID: task.ID, From: from, To: to}
+	}
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = to
+	if err := tx.Model(&models.Task{}).Where("id = ?", task.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&models.TaskTransition{
+		TaskID: task.ID,
+		From:   from,
+		To:     to,
+		Reason: reason,
+		UUID:   uuid,
+	}).Error; err != nil {
+		return err
+	}
+	log.Info("[TaskService] task transition",
+		zap.Uint("task_id", task.ID),
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.String("reason", reason),
+		zap.String("uuid", uuid))
+	task.Status = to
+	return nil
+}
+
+// transitionTaskByID locks a task and moves it to status to in its own transaction
+func (ts *taskService) transitionTaskByID(taskID uint, to, reason, uuid string) error {
+	return ts.DB.Transaction(func(tx *gorm.DB) error {
+		task, err := lockTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		return transitionTask(tx, task, to, reason, uuid, nil)
+	})
+}
+
+// abandonDispatch closes the log of a run that was never published and
+// moves its task back to ready, the reaper then has nothing to time out
+func (ts *taskService) abandonDispatch(taskID uint, uuid string, cause error) error {
+	return ts.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TaskLog{}).
+			Where("uuid = ? AND status = ?", uuid, models.TASK_RESULT_RUNNING).
+			Updates(map[string]interface{}{
+				"status":  models.TASK_RESULT_FAILED,
+				"err_msg": fmt.Sprintf("publish failed: %v", cause),
+			}).Error; err != nil {
+			return err
+		}
+		task, err := lockTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		return transitionTask(tx, task, models.TASK_STATUS_READY, "publish failed", uuid, nil)
+	})
+}
+
+// markDispatched moves a published task from queued to dispatched. A fast
+// node may have reported meanwhile, the task then keeps the status of its result.
+func (ts *taskService) markDispatched(taskID uint, uuid string) error {
+	return ts.DB.Transaction(func(tx *gorm.DB) error {
+		task, err := lockTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		if task.Status != models.TASK_STATUS_QUEUED {
+			log.Info("[TaskService] task reported before being marked dispatched",
+				zap.Uint("task_id", taskID),
+				zap.String("status", task.Status),
+				zap.String("uuid", uuid))
+			return nil
+		}
+		return transitionTask(tx, task, models.TASK_STATUS_DISPATCHED, "published", uuid, nil)
+	})
+}
+
+// GetTaskHistory returns the status changes of a task, oldest first
+func (ts *taskService) GetTaskHistory(ctx context.Context, taskID uint) ([]models.TaskTransition, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] GetTaskHistory", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
+
+	if _, serviceErr := ts.getAllowedTask(ctx, taskID, "read"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	var transitions []models.TaskTransition
+	if err := ts.DB.Where("task_id = ?", taskID).Order("id").Find(&transitions).Error; err != nil {
+		log.Error("[TaskService] GetTaskHistory", zap.String("traceID", traceID), zap.Uint("taskID", taskID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Task History Error", err)
+	}
+	return transitions, nil
+}
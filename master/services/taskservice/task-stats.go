@@ -0,0 +1,99 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultStatsRange  = 30 * 24 * time.Hour
+	maxFailureReasons  = 20
+	statsSelectColumns = "COUNT(*) AS dispatched, " +
+		"COALESCE(SUM(CASE WHEN status = %[1]d THEN 1 ELSE 0 END), 0) AS succeeded, " +
+		"COALESCE(SUM(CASE WHEN status = %[2]d THEN 1 ELSE 0 END), 0) AS failed, " +
+		"COALESCE(SUM(CASE WHEN status = %[3]d THEN 1 ELSE 0 END), 0) AS timed_out, " +
+		"COALESCE(SUM(CASE WHEN status = %[4]d THEN 1 ELSE 0 END), 0) AS running, " +
+		"COALESCE(AVG(CASE WHEN status = %[1]d AND back_timestamp > 0 AND dispatched_at > 0 " +
+		"THEN back_timestamp - dispatched_at END), 0) AS mean_round_trip"
+)
+
+// statsDay is the day a run was dispatched, old logs without a dispatch time
+// fall back to when they were written
+const statsDay = "CAST(DATE(CASE WHEN dispatched_at > 0 THEN FROM_UNIXTIME(dispatched_at) ELSE created_at END) AS CHAR)"
+
+// statsRange keeps the runs of the range by the same time as statsDay
+const statsRange = "(dispatched_at >= ? AND dispatched_at < ?) OR (dispatched_at = 0 AND created_at >= ? AND created_at < ?)"
+
+var statsColumns = fmt.Sprintf(statsSelectColumns,
+	models.TASK_RESULT_SUCCESS, models.TASK_RESULT_FAILED, models.TASK_RESULT_TIMEOUT, models.TASK_RESULT_RUNNING)
+
+// GetTaskStats aggregates the runs of a task between since and until, Unix
+// timestamps where 0 means the last 30 days and now
+func (ts *taskService) GetTaskStats(ctx context.Context, taskID uint, since, until int64) (*models.TaskStatsReport, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] GetTaskStats", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("taskID", taskID))
+
+	if _, serviceErr := ts.getAllowedTask(ctx, taskID, "read"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	return ts.taskStats(ctx, ts.DB.Where("task_id = ?", taskID), since, until)
+}
+
+// GetAccountStats aggregates the runs of an account, see GetTaskStats
+func (ts *taskService) GetAccountStats(ctx context.Context, accountID uint, since, until int64) (*models.TaskStatsReport, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] GetAccountStats", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("accountID", accountID))
+
+	if serviceErr := ts.checkAccountPermission(ctx, accountID, "read"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	return ts.taskStats(ctx, ts.accountLogScope(accountID), since, until)
+}
+
+func (ts *taskService) taskStats(ctx context.Context, scope *gorm.DB, since, until int64) (*models.TaskStatsReport, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	if until == 0 {
+		until = time.Now().Unix()
+	}
+	if since == 0 {
+		since = until - int64(defaultStatsRange/time.Second)
+	}
+	report := &models.TaskStatsReport{Since: since, Until: until}
+	logs := func() *gorm.DB {
+		return ts.DB.Model(&models.TaskLog{}).Where(scope).
+			Where(statsRange, since, until, time.Unix(since, 0), time.Unix(until, 0))
+	}
+
+	if err := logs().Select(statsColumns).Scan(&report.Summary).Error; err != nil {
+		log.Error("[TaskService] taskStats summary", zap.String("traceID", traceID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Task Stats Error", err)
+	}
+	report.Summary.SetRates()
+
+	if err := logs().Select(statsDay + " AS day, " + statsColumns).
+		Group("day").Order("day").Scan(&report.Daily).Error; err != nil {
+		log.Error("[TaskService] taskStats daily", zap.String("traceID", traceID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Task Stats Error", err)
+	}
+	for i := range report.Daily {
+		report.Daily[i].SetRates()
+	}
+
+	if err := logs().Select("err_msg, COUNT(*) AS count").
+		Where("status IN ?", []int{models.TASK_RESULT_FAILED, models.TASK_RESULT_TIMEOUT}).
+		Group("err_msg").Order("count DESC").Limit(maxFailureReasons).
+		Scan(&report.FailureReasons).Error; err != nil {
+		log.Error("[TaskService] taskStats failure reasons", zap.String("traceID", traceID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Task Stats Error", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,269 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// validateTemplate checks the spec of a template as a task would be checked
+func validateTemplate(template *models.TaskTemplate) error {
+	if template.Name == "" {
+		return errors.New("name is required")
+	}
+	if template.Spec.Name == "" {
+		template.Spec.Name = template.Name
+	}
+	template.Spec.StartPlanetID = 0 // every instance has its own
+
+	task := template.Spec.ToTask(0)
+	return validateTask(&task)
+}
+
+// CreateTemplate saves a template owned by the user in ctx
+func (ts *taskService) CreateTemplate(ctx context.Context, template *models.TaskTemplate) *utils.ServiceError {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] CreateTemplate", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.String("name", template.Name))
+
+	if err := validateTemplate(template); err != nil {
+		log.Warn("[TaskService] CreateTemplate invalid template", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		return utils.NewServiceError(http.StatusBadRequest, "Invalid Template", err)
+	}
+	template.ID = 0
+	template.UserID = userID
+	template.Version = 1
+	if err := ts.DB.Create(template).Error; err != nil {
+		log.Error("[TaskService] CreateTemplate", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		return utils.NewServiceError(http.StatusInternalServerError, "Create Template Error", err)
+	}
+	return nil
+}
+
+// ListTemplates returns the templates of the user in ctx
+func (ts *taskService) ListTemplates(ctx context.Context) ([]models.TaskTemplate, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ListTemplates", zap.String("traceID", traceID), zap.Uint("userID", userID))
+
+	var templates []models.TaskTemplate
+	if err := ts.DB.Where("user_id = ?", userID).Order("id").Find(&templates).Error; err != nil {
+		log.Error("[TaskService] ListTemplates", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Template Error", err)
+	}
+	return templates, nil
+}
+
+// GetTemplate returns a template of the user in ctx
+func (ts *taskService) GetTemplate(ctx context.Context, templateID uint) (*models.TaskTemplate, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] GetTemplate", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("templateID", templateID))
+	return ts.getOwnedTemplate(ctx, ts.DB, templateID)
+}
+
+// UpdateTemplate replaces the name and spec of a template and bumps its
+// version. Instances only change once ApplyTemplate runs.
+func (ts *taskService) UpdateTemplate(ctx context.Context, templateID uint, update *models.TaskTemplate) (*models.TaskTemplate, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] UpdateTemplate", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("templateID", templateID))
+
+	if err := validateTemplate(update); err != nil {
+		log.Warn("[TaskService] UpdateTemplate invalid template", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusBadRequest, "Invalid Template", err)
+	}
+	var template *models.TaskTemplate
+	var serviceErr *utils.ServiceError
+	err := ts.DB.Transaction(func(tx *gorm.DB) error {
+		if template, serviceErr = ts.getOwnedTemplate(ctx, tx.Clauses(clause.Locking{Strength: "UPDATE"}), templateID); serviceErr != nil {
+			return serviceErr
+		}
+		template.Name = update.Name
+		template.Spec = update.Spec
+		template.Version++
+		return tx.Save(template).Error
+	})
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if err != nil {
+		log.Error("[TaskService] UpdateTemplate", zap.String("traceID", traceID), zap.Uint("templateID", templateID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Update Template Error", err)
+	}
+	return template, nil
+}
+
+// DeleteTemplate deletes a template, its instances keep running as plain tasks
+func (ts *taskService) DeleteTemplate(ctx context.Context, templateID uint) *utils.ServiceError {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] DeleteTemplate", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("templateID", templateID))
+
+	if _, serviceErr := ts.getOwnedTemplate(ctx, ts.DB, templateID); serviceErr != nil {
+		return serviceErr
+	}
+	err := ts.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).Where("template_id = ?", templateID).
+			Updates(map[string]interface{}{"template_id": 0, "template_version": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.TaskTemplate{}, templateID).Error
+	})
+	if err != nil {
+		log.Error("[TaskService] DeleteTemplate", zap.String("traceID", traceID), zap.Uint("templateID", templateID), zap.Error(err))
+		return utils.NewServiceError(http.StatusInternalServerError, "Delete Template Error", err)
+	}
+	return nil
+}
+
+// InstantiateTemplate creates one task per instance from the current version
+// of a template. Either every task is created or none.
+func (ts *taskService) InstantiateTemplate(ctx context.Context, templateID uint, instances []models.TemplateInstance) ([]models.Task, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] InstantiateTemplate", zap.String("traceID", traceID), zap.Uint("userID", userID),
+		zap.Uint("templateID", templateID), zap.Int("instances", len(instances)))
+
+	template, serviceErr := ts.getOwnedTemplate(ctx, ts.DB, templateID)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if len(instances) == 0 {
+		return nil, utils.NewServiceError(http.StatusBadRequest, "No Instances", nil)
+	}
+	for _, instance := range instances {
+		if serviceErr := ts.checkAccountPermission(ctx, instance.AccountID, "write"); serviceErr != nil {
+			return nil, serviceErr
+		}
+		inventory, err := loadFleetInventory(ts.DB, instance.AccountID)
+		if err != nil {
+			log.Error("[TaskService] InstantiateTemplate", zap.String("traceID", traceID), zap.Uint("accountID", instance.AccountID), zap.Error(err))
+			return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Fleet Inventory Error", err)
+		}
+		task := template.Spec.ToTask(instance.AccountID)
+		task.StartPlanetID = instance.StartPlanetID
+		if err := accountPlanetsError(&task, true, inventory); err != nil {
+			log.Warn("[TaskService] InstantiateTemplate invalid planets", zap.String("traceID", traceID), zap.Uint("accountID", instance.AccountID), zap.Error(err))
+			return nil, utils.NewServiceError(http.StatusBadRequest, "Invalid Task", fmt.Errorf("account %d: %w", instance.AccountID, err))
+		}
+	}
+
+	tasks := make([]models.Task, 0, len(instances))
+	err := ts.DB.Transaction(func(tx *gorm.DB) error {
+		for _, instance := range instances {
+			task := template.Spec.ToTask(instance.AccountID)
+			task.StartPlanetID = instance.StartPlanetID
+			task.TemplateID = template.ID
+			task.TemplateVersion = template.Version
+			if err := ts.createTask(ctx, tx, &task); err != nil {
+				return fmt.Errorf("account %d: %w", instance.AccountID, err)
+			}
+			tasks = append(tasks, task)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("[TaskService] InstantiateTemplate", zap.String("traceID", traceID), zap.Uint("templateID", templateID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Create Task Error", err)
+	}
+	go ts.Enforcer.ReloadPolicy()
+	for _, task := range tasks {
+		ts.reindexTask(ctx, task.ID)
+	}
+	log.Info("[TaskService] InstantiateTemplate Succeed", zap.String("traceID", traceID), zap.Uint("templateID", templateID), zap.Int("tasks", len(tasks)))
+	return tasks, nil
+}
+
+// ApplyTemplate brings every instance built from an older version up to the
+// current one. Targets, fleet and settings follow the template, the start
+// planet, runtime state and whether the instance is enabled are kept.
+func (ts *taskService) ApplyTemplate(ctx context.Context, templateID uint) ([]models.TaskImportChange, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ApplyTemplate", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("templateID", templateID))
+
+	template, serviceErr := ts.getOwnedTemplate(ctx, ts.DB, templateID)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	var instances []models.Task
+	if err := ts.DB.Preload("Targets", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Preload("Fleet").Preload("FleetPreset").
+		Where("template_id = ? AND template_version < ?", template.ID, template.Version).
+		Order("id").Find(&instances).Error; err != nil {
+		log.Error("[TaskService] ApplyTemplate", zap.String("traceID", traceID), zap.Uint("templateID", templateID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Task Error", err)
+	}
+	for _, task := range instances {
+		if serviceErr := ts.checkAccountPermission(ctx, task.AccountID, "write"); serviceErr != nil {
+			return nil, serviceErr
+		}
+		// The instance is rebuilt, its start planet is checked like a new one
+		updated := template.Spec.ToTask(task.AccountID)
+		updated.ID = task.ID
+		updated.StartPlanetID = task.StartPlanetID
+		if serviceErr := ts.checkAccountPlanets(ctx, ts.DB, &updated, true); serviceErr != nil {
+			return nil, serviceErr
+		}
+	}
+
+	changes := make([]models.TaskImportChange, 0, len(instances))
+	err := ts.DB.Transaction(func(tx *gorm.DB) error {
+		for _, task := range instances {
+			spec := template.Spec
+			spec.Enabled = task.Enabled
+			spec.StartPlanetID = task.StartPlanetID
+			change := models.TaskImportChange{Name: spec.Name, TaskID: task.ID, Action: models.TASK_IMPORT_UNCHANGED}
+			if change.Fields = task.ToSpec().Diff(spec); len(change.Fields) > 0 {
+				change.Action = models.TASK_IMPORT_UPDATE
+				if err := updateImportedTask(tx, task.ID, spec, change.Fields); err != nil {
+					return fmt.Errorf("task %d: %w", task.ID, err)
+				}
+			}
+			if err := tx.Model(&models.Task{}).Where("id = ?", task.ID).
+				Update("template_version", template.Version).Error; err != nil {
+				return err
+			}
+			changes = append(changes, change)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("[TaskService] ApplyTemplate", zap.String("traceID", traceID), zap.Uint("templateID", templateID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Update Task Error", err)
+	}
+	for _, change := range changes {
+		if change.Action == models.TASK_IMPORT_UPDATE {
+			ts.reindexTask(ctx, change.TaskID)
+		}
+	}
+	log.Info("[TaskService] ApplyTemplate Succeed", zap.String("traceID", traceID), zap.Uint("templateID", templateID),
+		zap.Int("version", template.Version), zap.Int("instances", len(changes)))
+	return changes, nil
+}
+
+// getOwnedTemplate loads a template and checks that it belongs to the user in ctx
+func (ts *taskService) getOwnedTemplate(ctx context.Context, db *gorm.DB, templateID uint) (*models.TaskTemplate, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	var template models.TaskTemplate
+	if err := db.First(&template, templateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewServiceError(http.StatusNotFound, "Template Not Found", err)
+		}
+		log.Error("[TaskService] failed to get template", zap.String("traceID", traceID), zap.Uint("templateID", templateID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Template Error", err)
+	}
+	if template.UserID != userID {
+		log.Warn("[TaskService] Permission Denied", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("templateID", templateID))
+		return nil, utils.NewServiceError(http.StatusForbidden, "Permission Denied", nil)
+	}
+	return &template, nil
+}
@@ -0,0 +1,180 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeEnforcer allows everything and fails AddPolicy for the subject failSub
+type fakeEnforcer struct {
+	failSub string
+}
+
+func (e *fakeEnforcer) Enforce(ctx context.Context, sub, obj, act string) (bool, error) {
+	return true, nil
+}
+
+func (e *fakeEnforcer) AddPolicy(ctx context.Context, tx *gorm.DB, sub, obj, act string) (bool, error) {
+	if sub == e.failSub {
+		return false, errors.New("policy store unavailable")
+	}
+	return true, nil
+}
+
+func (e *fakeEnforcer) AddPolicies(ctx context.Context, tx *gorm.DB, rules [][]string) (bool, error) {
+	return true, nil
+}
+
+func (e *fakeEnforcer) AddUserToGroup(ctx context.Context, tx *gorm.DB, user, group string) (bool, error) {
+	return true, nil
+}
+
+func (e *fakeEnforcer) ReloadPolicy() error { return nil }
+
+func (e *fakeEnforcer) Stop() {}
+
+// newTemplateService returns a service over a database holding a template of
+// user 1 and the inventories of accounts 5 (planet 7) and 6 (planet 8)
+func newTemplateService(t *testing.T) (*taskService, *fakeEnforcer, *models.TaskTemplate, context.Context) {
+	t.Helper()
+	db := newTestDB(t, &models.TaskTemplate{}, &models.FleetInventory{}, &models.FleetPreset{})
+	for _, planet := range []models.FleetInventory{
+		{AccountID: 5, PlanetID: 7, Position: "1:2:3:0"},
+		{AccountID: 6, PlanetID: 8, Position: "2:3:4:0"},
+	} {
+		if err := db.Create(&planet).Error; err != nil {
+			t.Fatalf("create inventory: %v", err)
+		}
+	}
+	template := &models.TaskTemplate{UserID: 1, Name: "farm", Version: 1, Spec: models.TaskSpec{
+		Name: "farm", TaskType: models.TASKTYPE_ATTACK, Enabled: true, Repeat: 1,
+		Targets: []models.TargetSpec{{Galaxy: 1, System: 5, Planet: 5}},
+		Fleet:   models.FleetDTO{LightFighter: 10},
+	}}
+	if err := db.Create(template).Error; err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+	enforcer := &fakeEnforcer{}
+	ctx := context.WithValue(context.Background(), "userID", uint(1))
+	return &taskService{DB: db, Enforcer: enforcer}, enforcer, template, ctx
+}
+
+func countTasks(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.Task{}).Count(&count).Error; err != nil {
+		t.Fatalf("count tasks: %v", err)
+	}
+	return count
+}
+
+func Test_taskService_InstantiateTemplate(t *testing.T) {
+	ts, enforcer, template, ctx := newTemplateService(t)
+
+	// Planet 7 belongs to account 5, not to account 6
+	_, serviceErr := ts.InstantiateTemplate(ctx, template.ID, []models.TemplateInstance{{AccountID: 5, StartPlanetID: 7}, {AccountID: 6, StartPlanetID: 7}})
+	if serviceErr == nil || serviceErr.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("InstantiateTemplate() foreign start planet error = %v, want %d", serviceErr, http.StatusBadRequest)
+	}
+	// The policies of the second task can not be written
+	enforcer.failSub = "6"
+	if _, serviceErr := ts.InstantiateTemplate(ctx, template.ID, []models.TemplateInstance{{AccountID: 5, StartPlanetID: 7}, {AccountID: 6, StartPlanetID: 8}}); serviceErr == nil {
+		t.Fatal("InstantiateTemplate() succeeded without policies")
+	}
+	if count := countTasks(t, ts.DB); count != 0 {
+		t.Fatalf("tasks after failed instantiations = %d, want none", count)
+	}
+
+	enforcer.failSub = ""
+	tasks, serviceErr := ts.InstantiateTemplate(ctx, template.ID, []models.TemplateInstance{{AccountID: 5, StartPlanetID: 7}, {AccountID: 6, StartPlanetID: 8}})
+	if serviceErr != nil {
+		t.Fatalf("InstantiateTemplate() error = %v", serviceErr)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("InstantiateTemplate() = %d tasks, want 2", len(tasks))
+	}
+	for i, want := range []struct{ account, planet uint }{{5, 7}, {6, 8}} {
+		var task models.Task
+		if err := ts.DB.Preload("Targets").First(&task, tasks[i].ID).Error; err != nil {
+			t.Fatalf("find task: %v", err)
+		}
+		if task.AccountID != want.account || task.StartPlanetID != want.planet || task.TemplateID != template.ID ||
+			task.TemplateVersion != 1 || task.Status != models.TASK_STATUS_READY || len(task.Targets) != 1 {
+			t.Errorf("task %d = %+v, want account %d starting from %d", i, task, want.account, want.planet)
+		}
+	}
+}
+
+func Test_taskService_ApplyTemplate(t *testing.T) {
+	ts, _, template, ctx := newTemplateService(t)
+	tasks, serviceErr := ts.InstantiateTemplate(ctx, template.ID, []models.TemplateInstance{{AccountID: 5, StartPlanetID: 7}, {AccountID: 6, StartPlanetID: 8}})
+	if serviceErr != nil {
+		t.Fatalf("InstantiateTemplate() error = %v", serviceErr)
+	}
+	paused := tasks[1].ID
+	if _, serviceErr := ts.PauseTask(ctx, paused); serviceErr != nil {
+		t.Fatalf("PauseTask() error = %v", serviceErr)
+	}
+
+	update := &models.TaskTemplate{Name: "farm", Spec: template.Spec}
+	update.Spec.Repeat = 3
+	update.Spec.Targets = append(update.Spec.Targets, models.TargetSpec{Galaxy: 1, System: 5, Planet: 6})
+	updated, serviceErr := ts.UpdateTemplate(ctx, template.ID, update)
+	if serviceErr != nil {
+		t.Fatalf("UpdateTemplate() error = %v", serviceErr)
+	}
+	if updated.Version != 2 {
+		t.Fatalf("template version = %d, want 2", updated.Version)
+	}
+
+	changes, serviceErr := ts.ApplyTemplate(ctx, template.ID)
+	if serviceErr != nil {
+		t.Fatalf("ApplyTemplate() error = %v", serviceErr)
+	}
+	if len(changes) != 2 || changes[0].Action != models.TASK_IMPORT_UPDATE || changes[1].Action != models.TASK_IMPORT_UPDATE {
+		t.Fatalf("ApplyTemplate() = %+v, want both instances updated", changes)
+	}
+	for i, want := range []struct {
+		planet  uint
+		enabled bool
+	}{{7, true}, {8, false}} {
+		var task models.Task
+		if err := ts.DB.Preload("Targets").First(&task, tasks[i].ID).Error; err != nil {
+			t.Fatalf("find task: %v", err)
+		}
+		if task.TemplateVersion != 2 || task.Repeat != 3 || len(task.Targets) != 2 {
+			t.Errorf("task %d = version %d, repeat %d, %d targets, want the template of version 2",
+				i, task.TemplateVersion, task.Repeat, len(task.Targets))
+		}
+		if task.StartPlanetID != want.planet || task.Enabled != want.enabled {
+			t.Errorf("task %d = start planet %d, enabled %v, want %d, %v kept", i, task.StartPlanetID, task.Enabled, want.planet, want.enabled)
+		}
+	}
+	if changes, serviceErr := ts.ApplyTemplate(ctx, template.ID); serviceErr != nil || len(changes) != 0 {
+		t.Errorf("ApplyTemplate() again = %+v, %v, want no instance left behind", changes, serviceErr)
+	}
+
+	// An instance whose start planet left the account blocks the whole apply
+	if err := ts.DB.Where("planet_id = ?", 8).Delete(&models.FleetInventory{}).Error; err != nil {
+		t.Fatalf("delete inventory: %v", err)
+	}
+	update.Spec.Repeat = 4
+	if _, serviceErr := ts.UpdateTemplate(ctx, template.ID, update); serviceErr != nil {
+		t.Fatalf("UpdateTemplate() error = %v", serviceErr)
+	}
+	if _, serviceErr := ts.ApplyTemplate(ctx, template.ID); serviceErr == nil || serviceErr.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("ApplyTemplate() lost start planet error = %v, want %d", serviceErr, http.StatusBadRequest)
+	}
+	var versions []int
+	if err := ts.DB.Model(&models.Task{}).Order("id").Pluck("template_version", &versions).Error; err != nil {
+		t.Fatalf("find versions: %v", err)
+	}
+	if versions[0] != 2 || versions[1] != 2 {
+		t.Errorf("template versions = %v, want [2 2] after the refused apply", versions)
+	}
+}
@@ -0,0 +1,267 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExportTasks returns the tasks of an account as a portable document
+func (ts *taskService) ExportTasks(ctx context.Context, accountID uint) (*models.TaskDocument, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ExportTasks", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("accountID", accountID))
+
+	if serviceErr := ts.checkAccountPermission(ctx, accountID, "read"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	tasks, err := loadAccountTasks(ts.DB, accountID)
+	if err != nil {
+		log.Error("[TaskService] ExportTasks", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Task Error", err)
+	}
+	doc := &models.TaskDocument{Version: models.TASK_DOCUMENT_VERSION, Tasks: make([]models.TaskSpec, 0, len(tasks))}
+	for _, task := range tasks {
+		doc.Tasks = append(doc.Tasks, task.ToSpec())
+	}
+	return doc, nil
+}
+
+// ImportTasks creates or updates the tasks of an account from a document,
+// matching them by name. The whole document is validated first and applied
+// in one transaction. A dry run only returns the changes.
+func (ts *taskService) ImportTasks(ctx context.Context, accountID uint, doc *models.TaskDocument, dryRun bool) (*models.TaskImportResult, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ImportTasks", zap.String("traceID", traceID), zap.Uint("userID", userID),
+		zap.Uint("accountID", accountID), zap.Int("tasks", len(doc.Tasks)), zap.Bool("dryRun", dryRun))
+
+	if serviceErr := ts.checkAccountPermission(ctx, accountID, "write"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	if err := validateTaskDocument(doc, accountID); err != nil {
+		log.Warn("[TaskService] ImportTasks invalid document", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusBadRequest, "Invalid Task Document", err)
+	}
+	existing, err := loadAccountTasks(ts.DB, accountID)
+	if err != nil {
+		log.Error("[TaskService] ImportTasks", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Task Error", err)
+	}
+	result, err := planImport(doc, existing)
+	if err != nil {
+		log.Warn("[TaskService] ImportTasks conflicting document", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusConflict, "Ambiguous Task Name", err)
+	}
+	inventory, err := loadFleetInventory(ts.DB, accountID)
+	if err != nil {
+		log.Error("[TaskService] ImportTasks", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Get Fleet Inventory Error", err)
+	}
+	if err := checkImportedTasks(doc, result, inventory); err != nil {
+		log.Warn("[TaskService] ImportTasks invalid task for the account", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusBadRequest, "Invalid Task Document", err)
+	}
+	result.DryRun = dryRun
+	if dryRun {
+		return result, nil
+	}
+
+	created := false
+	err = ts.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range result.Changes {
+			change := &result.Changes[i]
+			spec := doc.Tasks[i]
+			switch change.Action {
+			case models.TASK_IMPORT_CREATE:
+				task := spec.ToTask(accountID)
+				if err := ts.createTask(ctx, tx, &task); err != nil {
+					return fmt.Errorf("create %q: %w", spec.Name, err)
+				}
+				change.TaskID = task.ID
+				created = true
+			case models.TASK_IMPORT_UPDATE:
+				if err := updateImportedTask(tx, change.TaskID, spec, change.Fields); err != nil {
+					return fmt.Errorf("update %q: %w", spec.Name, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("[TaskService] ImportTasks", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, utils.NewServiceError(http.StatusInternalServerError, "Import Task Error", err)
+	}
+	if created {
+		go ts.Enforcer.ReloadPolicy()
+	}
+	for _, change := range result.Changes {
+		if change.Action != models.TASK_IMPORT_UNCHANGED {
+			ts.reindexTask(ctx, change.TaskID)
+		}
+	}
+	log.Info("[TaskService] ImportTasks Succeed", zap.String("traceID", traceID), zap.Uint("accountID", accountID))
+	return result, nil
+}
+
+func loadAccountTasks(db *gorm.DB, accountID uint) ([]models.Task, error) {
+	var tasks []models.Task
+	err := db.Preload("Targets", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Fleet").Preload("FleetPreset").
+		Where("account_id = ?", accountID).Order("id").Find(&tasks).Error
+	return tasks, err
+}
+
+// validateTaskDocument checks every task of the document and reports all the problems at once
+func validateTaskDocument(doc *models.TaskDocument, accountID uint) error {
+	var errs []error
+	names := make(map[string]bool, len(doc.Tasks))
+	for i, spec := range doc.Tasks {
+		if spec.Name == "" {
+			errs = append(errs, fmt.Errorf("task %d: name is required", i+1))
+			continue
+		}
+		if names[spec.Name] {
+			errs = append(errs, fmt.Errorf("task %q: name used more than once", spec.Name))
+		}
+		names[spec.Name] = true
+		task := spec.ToTask(accountID)
+		if err := validateTask(&task); err != nil {
+			errs = append(errs, fmt.Errorf("task %q: %w", spec.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkImportedTasks checks the created and updated tasks of an import against
+// the planets of the account, see accountPlanetsError
+func checkImportedTasks(doc *models.TaskDocument, result *models.TaskImportResult, inventory map[uint]models.FleetInventory) error {
+	var errs []error
+	for i, change := range result.Changes {
+		if change.Action == models.TASK_IMPORT_UNCHANGED {
+			continue
+		}
+		task := doc.Tasks[i].ToTask(0)
+		newStart := change.Action == models.TASK_IMPORT_CREATE || slices.Contains(change.Fields, "start_planet_id")
+		if err := accountPlanetsError(&task, newStart, inventory); err != nil {
+			errs = append(errs, fmt.Errorf("task %q: %w", change.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// planImport compares the document with the existing tasks, the changes are in
+// document order. A task of the document without start planet keeps the one of
+// the existing task.
+func planImport(doc *models.TaskDocument, existing []models.Task) (*models.TaskImportResult, error) {
+	byName := make(map[string]*models.Task, len(existing))
+	duplicated := make(map[string]bool)
+	for i := range existing {
+		if _, ok := byName[existing[i].Name]; ok {
+			duplicated[existing[i].Name] = true
+		}
+		byName[existing[i].Name] = &existing[i]
+	}
+	result := &models.TaskImportResult{Changes: make([]models.TaskImportChange, 0, len(doc.Tasks))}
+	var errs []error
+	for i := range doc.Tasks {
+		spec := &doc.Tasks[i]
+		change := models.TaskImportChange{Name: spec.Name, Action: models.TASK_IMPORT_CREATE}
+		if duplicated[spec.Name] {
+			errs = append(errs, fmt.Errorf("task %q: several tasks of the account have this name", spec.Name))
+		} else if task, ok := byName[spec.Name]; ok {
+			if spec.StartPlanetID == 0 {
+				spec.StartPlanetID = task.StartPlanetID
+			}
+			change.TaskID = task.ID
+			change.Fields = task.ToSpec().Diff(*spec)
+			change.Action = models.TASK_IMPORT_UPDATE
+			if len(change.Fields) == 0 {
+				change.Action = models.TASK_IMPORT_UNCHANGED
+			}
+		}
+		result.Changes = append(result.Changes, change)
+	}
+	return result, errors.Join(errs...)
+}
+
+// createTask creates a task and its policies the same way AddTask does,
+// the caller reloads the policies after commit
+func (ts *taskService) createTask(ctx context.Context, tx *gorm.DB, task *models.Task) error {
+	task.Status = models.TASK_STATUS_READY
+	if err := tx.Create(task).Error; err != nil {
+		return err
+	}
+	sub := strconv.Itoa(int(task.AccountID))
+	obj := task.GetEntityPrefix() + strconv.Itoa(int(task.ID))
+	for _, act := range []string{"write", "read"} {
+		if _, err := ts.Enforcer.AddPolicy(ctx, tx, sub, obj, act); err != nil {
+			return fmt.Errorf("casbin add policy: %w", err)
+		}
+	}
+	return nil
+}
+
+// updateImportedTask overwrites the configuration of a task, changed targets and
+// fleet are replaced. Enabling and disabling go through transitions like UpdateTask.
+func updateImportedTask(tx *gorm.DB, taskID uint, spec models.TaskSpec, fields []string) error {
+	changed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		changed[field] = true
+	}
+	task, err := lockTask(tx, taskID)
+	if err != nil {
+		return err
+	}
+	if spec.Enabled && task.Status == models.TASK_STATUS_CANCELLED {
+		err = transitionTask(tx, task, models.TASK_STATUS_READY, "enabled by import", "", nil)
+	} else if !spec.Enabled && models.CanTransition(task.Status, models.TASK_STATUS_CANCELLED) {
+		err = transitionTask(tx, task, models.TASK_STATUS_CANCELLED, "disabled by import", "", nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	spec.ApplyTo(task)
+	if changed["fleet"] {
+		task.FleetPresetID = 0 // the document sets the ships explicitly
+	}
+	if changed["targets"] {
+		// The selection state pointed into the old list
+		task.NextIndex = 0
+		task.RetryTargetID = 0
+	}
+	if err := tx.Omit(clause.Associations).Save(task).Error; err != nil {
+		return err
+	}
+	if changed["targets"] {
+		if err := tx.Where("task_id = ?", task.ID).Delete(&models.Target{}).Error; err != nil {
+			return err
+		}
+		for i := range task.Targets {
+			task.Targets[i].TaskID = task.ID
+		}
+		if len(task.Targets) > 0 {
+			if err := tx.Create(&task.Targets).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if changed["fleet"] {
+		if err := tx.Where("task_id = ?", task.ID).Delete(&models.Fleet{}).Error; err != nil {
+			return err
+		}
+		task.Fleet.TaskID = task.ID
+		return tx.Create(&task.Fleet).Error
+	}
+	return nil
+}
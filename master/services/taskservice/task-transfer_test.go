@@ -0,0 +1,72 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func Test_planImport(t *testing.T) {
+	existing := []models.Task{
+		{Model: gorm.Model{ID: 1}, Name: "same", TaskType: models.TASKTYPE_ATTACK, Repeat: 1},
+		{Model: gorm.Model{ID: 2}, Name: "changed", TaskType: models.TASKTYPE_ATTACK, Repeat: 1, StartPlanetID: 7},
+	}
+	changed := existing[1].ToSpec()
+	changed.Repeat = 3
+	changed.StartPlanetID = 0 // documents without start planet keep the existing one
+	doc := &models.TaskDocument{Version: models.TASK_DOCUMENT_VERSION, Tasks: []models.TaskSpec{
+		existing[0].ToSpec(), changed, {Name: "new", TaskType: models.TASKTYPE_EXPLORE},
+	}}
+
+	result, err := planImport(doc, existing)
+	if err != nil {
+		t.Fatalf("planImport() error = %v", err)
+	}
+	want := []models.TaskImportChange{
+		{Name: "same", Action: models.TASK_IMPORT_UNCHANGED, TaskID: 1},
+		{Name: "changed", Action: models.TASK_IMPORT_UPDATE, TaskID: 2, Fields: []string{"repeat"}},
+		{Name: "new", Action: models.TASK_IMPORT_CREATE},
+	}
+	if !reflect.DeepEqual(result.Changes, want) {
+		t.Errorf("planImport() = %+v, want %+v", result.Changes, want)
+	}
+	if doc.Tasks[1].StartPlanetID != 7 {
+		t.Errorf("start planet of the update = %d, want 7", doc.Tasks[1].StartPlanetID)
+	}
+
+	// A name shared by two tasks can not be matched
+	existing = append(existing, models.Task{Model: gorm.Model{ID: 3}, Name: "same"})
+	if _, err := planImport(doc, existing); err == nil {
+		t.Error("planImport() accepted an ambiguous name")
+	}
+}
+
+func Test_checkImportedTasks(t *testing.T) {
+	inventory := map[uint]models.FleetInventory{7: {PlanetID: 7, Position: "1:2:3:0"}}
+	legacy := models.TaskSpec{Name: "legacy", TaskType: models.TASKTYPE_ATTACK} // saved before start planets were required
+	tests := []struct {
+		name      string
+		spec      models.TaskSpec
+		change    models.TaskImportChange
+		inventory map[uint]models.FleetInventory
+		wantErr   bool
+	}{
+		{"created on a planet of the account", models.TaskSpec{Name: "new", StartPlanetID: 7}, models.TaskImportChange{Action: models.TASK_IMPORT_CREATE}, inventory, false},
+		{"created without start planet", models.TaskSpec{Name: "new"}, models.TaskImportChange{Action: models.TASK_IMPORT_CREATE}, inventory, true},
+		{"created before the fleet was queried", models.TaskSpec{Name: "new", StartPlanetID: 7}, models.TaskImportChange{Action: models.TASK_IMPORT_CREATE}, nil, true},
+		{"moved to a foreign planet", models.TaskSpec{Name: "moved", StartPlanetID: 8}, models.TaskImportChange{Action: models.TASK_IMPORT_UPDATE, Fields: []string{"start_planet_id"}}, inventory, true},
+		{"updated, start planet kept", legacy, models.TaskImportChange{Action: models.TASK_IMPORT_UPDATE, Fields: []string{"repeat"}}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &models.TaskDocument{Version: models.TASK_DOCUMENT_VERSION, Tasks: []models.TaskSpec{tt.spec}}
+			tt.change.Name = tt.spec.Name
+			result := &models.TaskImportResult{Changes: []models.TaskImportChange{tt.change}}
+			if err := checkImportedTasks(doc, result, tt.inventory); (err != nil) != tt.wantErr {
+				t.Errorf("checkImportedTasks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
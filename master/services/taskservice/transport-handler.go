@@ -0,0 +1,86 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// transportHandler handles transport missions, fleet runs carrying the cargo
+// manifest of the task to a planet of the account
+type transportHandler struct {
+	fleetHandler
+}
+
+func (h *transportHandler) Validate(task *models.Task) error {
+	if err := h.fleetHandler.Validate(task); err != nil {
+		return err
+	}
+	if task.Cargo.IsEmpty() {
+		return errors.New("transport tasks need a cargo")
+	}
+	return nil
+}
+
+func (h *transportHandler) HandleResult(tx *gorm.DB, response *models.SingleTaskResponse) (*models.Task, error) {
+	task, err := h.fleetHandler.HandleResult(tx, response)
+	if err != nil || response.Status != models.TASK_RESULT_SUCCESS {
+		return task, err
+	}
+	if err := recordTransport(tx, response); err != nil {
+		log.Error("[transportHandler::HandleResult] failed to record transport",
+			zap.String("uuid", response.UUID),
+			zap.Uint("task_id", response.TaskID),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to record transport: %w", err)
+	}
+	return task, nil
+}
+
+// recordTransport saves the amounts a successful run delivered, reported by
+// the node in the payload of the result
+func recordTransport(tx *gorm.DB, response *models.SingleTaskResponse) error {
+	var delivered models.CargoManifest
+	if !decodePayload(response, &delivered) {
+		return nil // the run succeeded, only its amounts are unknown
+	}
+	taskLog, err := loadRun(tx, response.UUID)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.TransportRecord{
+		TaskID:        response.TaskID,
+		AccountID:     taskLog.AccountID,
+		UUID:          response.UUID,
+		TargetID:      taskLog.TargetID,
+		Metal:         delivered.Metal,
+		Crystal:       delivered.Crystal,
+		Deuterium:     delivered.Deuterium,
+		BackTimestamp: response.BackTimestamp,
+	}).Error
+}
+
+// ownTargetsError returns why a transport task may not deliver to its targets,
+// which must all be planets or moons of the account as the last fleet query
+// knows them. Other task types may target anyone.
+func ownTargetsError(task *models.Task, inventory map[uint]models.FleetInventory) error {
+	if task.TaskType != models.TASKTYPE_TRANSPORT {
+		return nil
+	}
+	if len(inventory) == 0 {
+		return errors.New("planets of the account unknown, query the fleet first")
+	}
+	own := make(map[string]bool, len(inventory))
+	for _, planet := range inventory {
+		own[planet.Position] = true
+	}
+	for _, target := range task.Targets {
+		if !own[target.String()] {
+			return fmt.Errorf("target %s is not a planet of the account", target.String())
+		}
+	}
+	return nil
+}
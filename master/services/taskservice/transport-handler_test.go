@@ -0,0 +1,34 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/models"
+	"testing"
+)
+
+func Test_ownTargetsError(t *testing.T) {
+	inventory := map[uint]models.FleetInventory{
+		7: {PlanetID: 7, Position: "1:2:3:0"},
+		8: {PlanetID: 8, Position: "1:2:3:1"},
+	}
+	transport := func(targets ...models.Target) *models.Task {
+		return &models.Task{TaskType: models.TASKTYPE_TRANSPORT, Targets: targets}
+	}
+	tests := []struct {
+		name      string
+		task      *models.Task
+		inventory map[uint]models.FleetInventory
+		wantErr   bool
+	}{
+		{"own planet and moon", transport(models.Target{Galaxy: 1, System: 2, Planet: 3}, models.Target{Galaxy: 1, System: 2, Planet: 3, Is_moon: true}), inventory, false},
+		{"foreign planet", transport(models.Target{Galaxy: 1, System: 2, Planet: 3}, models.Target{Galaxy: 1, System: 2, Planet: 4}), inventory, true},
+		{"planets never queried", transport(models.Target{Galaxy: 1, System: 2, Planet: 3}), nil, true},
+		{"attacks target anyone", &models.Task{TaskType: models.TASKTYPE_ATTACK, Targets: []models.Target{{Galaxy: 1, System: 2, Planet: 4}}}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ownTargetsError(tt.task, tt.inventory); (err != nil) != tt.wantErr {
+				t.Errorf("ownTargetsError() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package taskservice
+
+import (
+	"GalaxyEmpireWeb/config"
+	"GalaxyEmpireWeb/models"
+	"GalaxyEmpireWeb/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ScanGalaxy asks a node to walk a range of systems with an account. The
+// result updates the universe catalog of its server, it returns the uuid of the scan.
+func (ts *taskService) ScanGalaxy(ctx context.Context, accountID uint, scanRange models.ScanRange) (string, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ScanGalaxy", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("accountID", accountID),
+		zap.Int("galaxy", scanRange.Galaxy), zap.Int("system_from", scanRange.SystemFrom), zap.Int("system_to", scanRange.SystemTo))
+
+	if err := scanRange.Validate(config.SCAN_MAX_SYSTEMS); err != nil {
+		return "", utils.NewServiceError(http.StatusBadRequest, "Wrong Scan Range", err)
+	}
+	if serviceErr := ts.checkAccountPermission(ctx, accountID, "write"); serviceErr != nil {
+		return "", serviceErr
+	}
+	var account models.Account
+	if err := ts.DB.First(&account, accountID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", utils.NewServiceError(http.StatusNotFound, "Account Not Found", err)
+		}
+		log.Error("[TaskService] ScanGalaxy", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return "", utils.NewServiceError(http.StatusInternalServerError, "Get Account Error", err)
+	}
+	return ts.dispatchInstant(ctx, &account, &models.SingleTaskRequest{TaskType: models.TASKTYPE_SCAN_GALAXY, Scan: &scanRange})
+}
+
+// ListUniverse returns a page of the catalog of the server of an account,
+// ordered by position, and the number of planets matching the filter
+func (ts *taskService) ListUniverse(ctx context.Context, accountID uint, filter *models.UniverseFilter) ([]models.UniversePlanet, int64, *utils.ServiceError) {
+	traceID := utils.TraceIDFromContext(ctx)
+	userID := utils.UserIDFromContext(ctx)
+	log.Info("[TaskService] ListUniverse", zap.String("traceID", traceID), zap.Uint("userID", userID), zap.Uint("accountID", accountID))
+
+	if serviceErr := ts.checkAccountPermission(ctx, accountID, "read"); serviceErr != nil {
+		return nil, 0, serviceErr
+	}
+	var account models.Account
+	if err := ts.DB.Select("server").First(&account, accountID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, utils.NewServiceError(http.StatusNotFound, "Account Not Found", err)
+		}
+		log.Error("[TaskService] ListUniverse", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Get Account Error", err)
+	}
+
+	query := ts.DB.Model(&models.UniversePlanet{}).Where("server = ? AND galaxy = ?", account.Server, filter.Galaxy)
+	if filter.SystemFrom != 0 {
+		query = query.Where("solar >= ?", filter.SystemFrom)
+	}
+	if filter.SystemTo != 0 {
+		query = query.Where("solar <= ?", filter.SystemTo)
+	}
+	if filter.Player != "" {
+		query = query.Where("player_name = ?", filter.Player)
+	}
+	if filter.Alliance != "" {
+		query = query.Where("alliance = ?", filter.Alliance)
+	}
+	if filter.Inactive {
+		query = query.Where("inactive = ? OR long_inactive = ?", true, true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Error("[TaskService] ListUniverse", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Count Universe Error", err)
+	}
+	var planets []models.UniversePlanet
+	if err := query.Order("solar, location").
+		Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&planets).Error; err != nil {
+		log.Error("[TaskService] ListUniverse", zap.String("traceID", traceID), zap.Uint("accountID", accountID), zap.Error(err))
+		return nil, 0, utils.NewServiceError(http.StatusInternalServerError, "Get Universe Error", err)
+	}
+	return planets, total, nil
+}
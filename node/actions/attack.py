@@ -1,18 +1,25 @@
 import logging
+import time
+from functools import partial
 from queue import Queue
+from typing import Optional
 from model.task import Task, TaskType, TaskResult, TaskStatus
 from galaxy_core import Galaxy
+from report_scheduler import ReportScheduler
 
 logger = logging.getLogger(__name__)
 
+EXPEDITION_STAY = 3600  # staytime of handle_explore_task, in seconds
 
-def attack_action(task: Task, result_queue: Queue):
+
+def attack_action(task: Task, result_queue: Queue, reports: ReportScheduler):
     """
-    Handle attack task and put result in queue.
+    Handle attack task and put result in queue, the battle report follows once the fleets arrived.
 
     Args:
         task (Task): The attack task to process
         result_queue (Queue): Queue to put task results
+        reports (ReportScheduler): Reads the battle report later
     """
     task_status = TaskStatus.SUCCESS
     uuid = task.uuid
@@ -37,6 +44,7 @@ def attack_action(task: Task, result_queue: Queue):
                 raise Exception("Change planet failed")
             else:
                 logger.info(f"Change planet {task.start_planet_id} completed successfully")
+            sent_at = int(time.time())
             attack_response = galaxy.handle_attack_task(task)
 
             if attack_response.status != 0:
@@ -48,6 +56,9 @@ def attack_action(task: Task, result_queue: Queue):
                     logger.warning("Invalid back_ts in response")
                     task_status = TaskStatus.FAILED
                 else:
+                    # The battle happens when the fleets arrive, halfway through the flight
+                    reports.schedule(time.time() + max(0, (back_ts - time.time()) / 2) + 2,
+                                     partial(read_battle_report, task, result_queue, sent_at, back_ts))
                     logger.info(f"Attack task {task.task_id} completed successfully")
 
     except Exception as e:
@@ -65,13 +76,14 @@ def attack_action(task: Task, result_queue: Queue):
         logger.debug(f"Result queued for task {task.task_id}: {result}")
 
 
-def explore_action(task: Task, result_queue: Queue):
+def explore_action(task: Task, result_queue: Queue, reports: ReportScheduler):
     """
-    Handle explore task and put result in queue.
+    Handle explore task and put result in queue, the expedition message follows once it is written.
 
     Args:
         task (Task): The explore task to process
         result_queue (Queue): Queue to put task results
+        reports (ReportScheduler): Reads the expedition message later
     """
     task_status = TaskStatus.SUCCESS
     back_ts = -1
@@ -96,6 +108,7 @@ def explore_action(task: Task, result_queue: Queue):
             else:
                 logger.info(f"Change planet {task.start_planet_id} completed successfully")
 
+            sent_at = int(time.time())
             response = galaxy.handle_explore_task(task)
             if response.status != 0:
                 logger.warning(f"Explore task failed: {response.err_msg}")
@@ -106,6 +119,11 @@ def explore_action(task: Task, result_queue: Queue):
                     logger.warning("Invalid back_ts in response")
                     task_status = TaskStatus.FAILED
                 else:
+                    # The message is written when the fleets leave the expedition
+                    # after their hour of stay, one flight before they are back
+                    flight = max(0, (back_ts - sent_at - EXPEDITION_STAY) / 2)
+                    reports.schedule(max(time.time(), back_ts - flight) + 2,
+                                     partial(read_expedition_report, task, result_queue, sent_at, back_ts))
                     logger.info(f"Explore task {task.task_id} completed successfully")
 
     except Exception as e:
@@ -121,3 +139,67 @@ def explore_action(task: Task, result_queue: Queue):
         )
         result_queue.put(result)
         logger.debug(f"Result queued for task {task.task_id}: {result}")
+
+
+def read_battle_report(task: Task, result_queue: Queue, sent_at: int, back_ts: int) -> Optional[TaskResult]:
+    """
+    Read the battle report of an attack run, called by the ReportScheduler.
+
+    Args:
+        task (Task): The attack task the fleets were sent for
+        result_queue (Queue): Queue the Galaxy instance reports to
+        sent_at (int): Unix timestamp the fleets were sent at
+        back_ts (int): Unix timestamp the fleets are back
+
+    Returns:
+        TaskResult: The report of the run, None when it could not be read
+    """
+    galaxy = Galaxy(task.account, result_queue)
+    login_response = galaxy.login()
+    if login_response.status != 0:
+        logger.warning(f"Login failed, no battle report for attack task {task.task_id}: {login_response.err_msg}")
+        return None
+    report = galaxy.get_battle_report(task, sent_at)
+    if report.status != 0:
+        logger.warning(f"No battle report for attack task {task.task_id}: {report.err_msg}")
+        return None
+    return TaskResult(
+        task_id=task.task_id,
+        status=TaskStatus.REPORT,
+        task_type=task.task_type,
+        back_ts=back_ts,
+        uuid=task.uuid,
+        data=report.data
+    )
+
+
+def read_expedition_report(task: Task, result_queue: Queue, sent_at: int, back_ts: int) -> Optional[TaskResult]:
+    """
+    Read the expedition message of an explore run, called by the ReportScheduler.
+
+    Args:
+        task (Task): The explore task the fleets were sent for
+        result_queue (Queue): Queue the Galaxy instance reports to
+        sent_at (int): Unix timestamp the fleets were sent at
+        back_ts (int): Unix timestamp the fleets are back
+
+    Returns:
+        TaskResult: The report of the run, None when it could not be read
+    """
+    galaxy = Galaxy(task.account, result_queue)
+    login_response = galaxy.login()
+    if login_response.status != 0:
+        logger.warning(f"Login failed, no expedition message for explore task {task.task_id}: {login_response.err_msg}")
+        return None
+    report = galaxy.get_expedition_report(task, sent_at)
+    if report.status != 0:
+        logger.warning(f"No expedition message for explore task {task.task_id}: {report.err_msg}")
+        return None
+    return TaskResult(
+        task_id=task.task_id,
+        status=TaskStatus.REPORT,
+        task_type=task.task_type,
+        back_ts=back_ts,
+        uuid=task.uuid,
+        data=report.data
+    )
@@ -0,0 +1,31 @@
+import logging
+from queue import Queue
+from model.task import Task, TaskStatus, TaskResult
+from galaxy_core import Galaxy
+
+logger = logging.getLogger(__name__)
+
+
+def scan_galaxy_action(task: Task, result_queue: Queue):
+    uuid = task.uuid
+    back_ts = -1
+    task_result = TaskResult(task_id=task.task_id, status=TaskStatus.SUCCESS, task_type=task.task_type, back_ts=back_ts, uuid=uuid, msg="", err_msg="")
+    try:
+        if not task.scan:
+            raise Exception("scan range missing")
+        galaxy = Galaxy(task.account, result_queue=result_queue)
+        login_response = galaxy.login()
+        if login_response.status != 0:
+            logger.warning(f"Login failed: {login_response.err_msg}")
+            raise Exception(login_response.err_msg)
+        scan_response = galaxy.scan_galaxy(task.scan)
+        if scan_response.status != 0:
+            task_result.status = TaskStatus.FAILED
+            task_result.err_msg = scan_response.err_msg
+        else:
+            task_result.data = scan_response.data
+    except Exception as e:
+        task_result.status = TaskStatus.FAILED
+        task_result.err_msg = str(e)
+    finally:
+        result_queue.put(task_result)
@@ -0,0 +1,103 @@
+import logging
+import time
+from functools import partial
+from queue import Queue
+from typing import Optional
+from model.task import Task, TaskType, TaskResult, TaskStatus
+from galaxy_core import Galaxy
+from report_scheduler import ReportScheduler
+
+logger = logging.getLogger(__name__)
+
+
+def spy_action(task: Task, result_queue: Queue, reports: ReportScheduler):
+    """
+    Handle spy task and put result in queue, the report follows once the probes arrived.
+
+    Args:
+        task (Task): The spy task to process
+        result_queue (Queue): Queue to put task results
+        reports (ReportScheduler): Reads the spy report later
+    """
+    task_status = TaskStatus.SUCCESS
+    back_ts = -1
+    msg = ""
+    err_msg = ""
+
+    try:
+        if task.task_type != TaskType.SPY:
+            logger.error(f"Invalid task type for spy_action: {task.task_type}")
+            task_status = TaskStatus.FAILED
+        else:
+            logger.info(f"Processing spy task {task.task_id}")
+            galaxy = Galaxy(task.account, result_queue)
+            login_response = galaxy.login()
+            if login_response.status != 0:
+                logger.warning(f"Login failed: {login_response.err_msg}")
+                task_status = TaskStatus.FAILED
+                raise Exception("Login failed")
+            change_planet_response = galaxy.change_planet(task.start_planet_id)
+            if change_planet_response.status != 0:
+                logger.warning(f"Change planet failed: {change_planet_response.err_msg}")
+                task_status = TaskStatus.FAILED
+                raise Exception("Change planet failed")
+
+            response = galaxy.handle_spy_task(task)
+            if response.status != 0:
+                logger.warning(f"Spy task failed: {response.err_msg}")
+                task_status = TaskStatus.FAILED
+                err_msg = response.err_msg
+            else:
+                back_ts = response.data.get('back_ts', -1)
+                # The report is written when the probes arrive, halfway through the flight
+                reports.schedule(time.time() + max(0, (back_ts - time.time()) / 2) + 2,
+                                 partial(read_spy_report, task, result_queue, back_ts))
+                logger.info(f"Spy task {task.task_id} completed successfully")
+
+    except Exception as e:
+        logger.error(f"Error processing spy task {task.task_id}: {str(e)}", exc_info=True)
+        task_status = TaskStatus.FAILED
+        err_msg = str(e)
+    finally:
+        result = TaskResult(
+            task_id=task.task_id,
+            status=task_status,
+            task_type=task.task_type,
+            back_ts=back_ts,
+            uuid=task.uuid,
+            msg=msg,
+            err_msg=err_msg
+        )
+        result_queue.put(result)
+        logger.debug(f"Result queued for task {task.task_id}: {result}")
+
+
+def read_spy_report(task: Task, result_queue: Queue, back_ts: int) -> Optional[TaskResult]:
+    """
+    Read the report of a spy run, called by the ReportScheduler.
+
+    Args:
+        task (Task): The spy task the probes were sent for
+        result_queue (Queue): Queue the Galaxy instance reports to
+        back_ts (int): Unix timestamp the probes are back
+
+    Returns:
+        TaskResult: The report of the run, None when it could not be read
+    """
+    galaxy = Galaxy(task.account, result_queue)
+    login_response = galaxy.login()
+    if login_response.status != 0:
+        logger.warning(f"Login failed, no report for spy task {task.task_id}: {login_response.err_msg}")
+        return None
+    report = galaxy.get_spy_report(task)
+    if report.status != 0:
+        logger.warning(f"No report for spy task {task.task_id}: {report.err_msg}")
+        return None
+    return TaskResult(
+        task_id=task.task_id,
+        status=TaskStatus.REPORT,
+        task_type=task.task_type,
+        back_ts=back_ts,
+        uuid=task.uuid,
+        data=report.data
+    )
@@ -0,0 +1,72 @@
+import logging
+from queue import Queue
+from model.task import Task, TaskType, TaskResult, TaskStatus
+from galaxy_core import Galaxy
+
+logger = logging.getLogger(__name__)
+
+
+def transport_action(task: Task, result_queue: Queue):
+    """
+    Handle transport task and put result in queue.
+
+    Args:
+        task (Task): The transport task to process
+        result_queue (Queue): Queue to put task results
+    """
+    task_status = TaskStatus.SUCCESS
+    back_ts = -1
+    msg = ""
+    err_msg = ""
+    data = None
+
+    try:
+        if task.task_type != TaskType.TRANSPORT:
+            logger.error(f"Invalid task type for transport_action: {task.task_type}")
+            task_status = TaskStatus.FAILED
+        else:
+            logger.info(f"Processing transport task {task.task_id}")
+            galaxy = Galaxy(task.account, result_queue)
+            login_response = galaxy.login()
+            if login_response.status != 0:
+                logger.warning(f"Login failed: {login_response.err_msg}")
+                task_status = TaskStatus.FAILED
+                raise Exception("Login failed")
+            change_planet_response = galaxy.change_planet(task.start_planet_id)
+            if change_planet_response.status != 0:
+                logger.warning(f"Change planet failed: {change_planet_response.err_msg}")
+                task_status = TaskStatus.FAILED
+                raise Exception("Change planet failed")
+
+            response = galaxy.handle_transport_task(task, change_planet_response.data)
+            if response.status != 0:
+                logger.warning(f"Transport task failed: {response.err_msg}")
+                task_status = TaskStatus.FAILED
+                err_msg = response.err_msg
+            else:
+                back_ts = response.data.get('back_ts', -1)
+                if back_ts == -1:
+                    logger.warning("Invalid back_ts in response")
+                    task_status = TaskStatus.FAILED
+                else:
+                    # Delivered amounts, recorded by the master
+                    data = {key: response.data[key] for key in ('metal', 'crystal', 'deuterium')}
+                    logger.info(f"Transport task {task.task_id} completed successfully")
+
+    except Exception as e:
+        logger.error(f"Error processing transport task {task.task_id}: {str(e)}", exc_info=True)
+        task_status = TaskStatus.FAILED
+        err_msg = str(e)
+    finally:
+        result = TaskResult(
+            task_id=task.task_id,
+            status=task_status,
+            task_type=task.task_type,
+            back_ts=back_ts,
+            uuid=task.uuid,
+            msg=msg,
+            err_msg=err_msg,
+            data=data
+        )
+        result_queue.put(result)
+        logger.debug(f"Result queued for task {task.task_id}: {result}")
@@ -3,6 +3,7 @@ RABBITMQ_HOST = os.environ.get('RABBITMQ_HOST', "localhost")
 RABBITMQ_USER = os.environ.get('RABBITMQ_USER', 'admin')
 RABBITMQ_PASS = os.environ.get('RABBITMQ_PASS', 'password')
 TASK_QUEUE = os.environ.get('TASK_QUEUE', 'task_queue')
+INSTANT_QUEUE = os.environ.get('INSTANT_QUEUE', 'instant_queue')
 RESULT_QUEUE = os.environ.get('RESULT_QUEUE', 'result_queue')
 DELAYED_EXCHANGE = os.environ.get('DELAYED_EXCHANGE', 'delayed_exchange')
 PROXY_BASE_URL = os.environ.get('PROXY_ENDPOINT', 'http://localhost:5010')
@@ -35,3 +36,20 @@ ShipToID = {
     'bomb': 'ship211',
     'guard': 'ship216'
 }
+
+IDToShip = {ship_id: ship for ship, ship_id in ShipToID.items()}
+
+# Resources one ship carries, ships of unknown capacity count as none
+ShipCapacity = {
+    'ds': 1000000,
+    'de': 2000,
+    'cargo': 25000,
+    'bs': 1500,
+    'satellite': 0,
+    'lf': 50,
+    'hf': 100,
+    'cr': 800,
+    'dr': 750,
+    'bomb': 500,
+    'guard': 0
+}
@@ -4,6 +4,8 @@ from queue import Queue
 from network import Network, NetworkResponse
 from model.user import Account
 from model.task import Task, TaskType, MissionType
+from model.scan import ScanRange
+from config import IDToShip
 
 logger = logging.getLogger(__name__)
 
@@ -29,6 +31,8 @@ class Galaxy(Network):
         args = {}
         mission_mapping = {
             TaskType.ATTACK: MissionType.ATTACK,
+            TaskType.TRANSPORT: MissionType.TRANSPORT,
+            TaskType.SPY: MissionType.SPY,
             TaskType.EXPLORE: MissionType.EXPLORE
         }
 
@@ -170,6 +174,237 @@ class Galaxy(Network):
             time.sleep(1)
         return NetworkResponse(status=0, data={'total_finish_ts': total_finish_ts})
 
+    def handle_transport_task(self, task: Task, planet_data: dict) -> NetworkResponse:
+        """
+        Handle a transport task.
+
+        Args:
+            task (Task): Task object.
+            planet_data (dict): Result of changing to the start planet, holds its resources.
+
+        Returns:
+            NetworkResponse: Contains backtime and the loaded amounts if successful.
+        """
+        if not self.ready:
+            err_msg = "Network not ready."
+            logger.error(err_msg)
+            return NetworkResponse(status=-1, data={}, err_msg=err_msg)
+        if not task.cargo:
+            return NetworkResponse(status=-1, data={}, err_msg="Transport task without cargo.")
+
+        # The game loads what the planet has and the ships hold, report that
+        stock = None
+        planet = planet_data.get("buildInfo", {}).get("result", {}).get("Planets", {}).get(str(task.start_planet_id))
+        if planet:
+            stock = {
+                'resource1': int(float(planet.get('metal', 0))),
+                'resource2': int(float(planet.get('crystal', 0))),
+                'resource3': int(float(planet.get('deuterium', 0))),
+            }
+        elif task.cargo.all:
+            return NetworkResponse(status=-1, data={}, err_msg="Resources of the start planet unknown.")
+        resources = task.cargo.load(stock, task.fleet.capacity())
+        if not any(resources.values()):
+            return NetworkResponse(status=-1, data={}, err_msg="Nothing to transport.")
+
+        response = self.prepare_fleet(task)
+        if response.status != 0:
+            logger.error("Error preparing fleet.")
+            return response
+
+        args = response.data['args']
+        args['token'] = response.data['token']
+        args.update(resources)
+        SEND_FLEET_END_POINT = "game.php?page=fleet3"
+
+        logger.info(f"Sending transport fleet with {resources}.")
+        send_response = self._post(SEND_FLEET_END_POINT, args)
+        if send_response.status != 0:
+            logger.error(f"Error sending transport fleet: {send_response.err_msg}")
+            return send_response
+
+        backtime = send_response.data.get('result', {}).get('back_ts', -1)
+        return NetworkResponse(status=0, data={
+            'back_ts': backtime,
+            'metal': resources['resource1'],
+            'crystal': resources['resource2'],
+            'deuterium': resources['resource3'],
+        })
+
+    def handle_spy_task(self, task: Task) -> NetworkResponse:
+        """
+        Handle a spy task, send the probes. Their report is read with
+        get_spy_report once they arrived.
+
+        Args:
+            task (Task): Task object.
+
+        Returns:
+            NetworkResponse: Contains backtime if successful.
+        """
+        response = self.handle_single_attack_task(task)
+        if response.status != 0:
+            logger.error("Error sending probes.")
+        return response
+
+    def get_spy_report(self, task: Task, max_retries: int = 3) -> NetworkResponse:
+        """
+        Read the latest spy report about the target of a task.
+
+        Args:
+            task (Task): Task object.
+            max_retries (int): Attempts before giving up on a report not written yet.
+
+        Returns:
+            NetworkResponse: Contains the report if successful.
+        """
+        MESSAGES_ENDPOINT = "game.php?page=messages"
+        for attempt in range(max_retries):
+            response = self._post(MESSAGES_ENDPOINT, {'mode': 'show', 'messcat': 0})
+            if response.status != 0:
+                return response
+            for message in response.data.get('result', {}).get('MessageList', []):
+                spy = message.get('spy')
+                if not spy:
+                    continue
+                if (int(spy.get('galaxy', 0)), int(spy.get('system', 0)), int(spy.get('planet', 0))) != \
+                        (task.target.galaxy, task.target.system, task.target.planet):
+                    continue
+                return NetworkResponse(status=0, data={
+                    'reported_at': int(message.get('time', time.time())),
+                    'resources': {
+                        'metal': int(float(spy.get('metal', 0))),
+                        'crystal': int(float(spy.get('crystal', 0))),
+                        'deuterium': int(float(spy.get('deuterium', 0))),
+                        'energy': int(float(spy.get('energy', 0))),
+                    },
+                    'fleet': spy.get('fleet'),
+                    'defense': spy.get('defense'),
+                    'buildings': spy.get('buildings'),  # missing when too few probes arrived
+                })
+            logger.info(f"Spy report not found yet (attempt {attempt + 1}/{max_retries})")
+            time.sleep(5)
+        return NetworkResponse(status=-1, data={}, err_msg="Spy report not found.")
+
+    def _read_messages(self, category: int) -> NetworkResponse:
+        """
+        Read the game messages of a category, 3 battle reports and 15 expeditions.
+
+        Args:
+            category (int): Message category.
+
+        Returns:
+            NetworkResponse: Contains the message list if successful.
+        """
+        MESSAGES_ENDPOINT = "game.php?page=messages"
+        response = self._post(MESSAGES_ENDPOINT, {'mode': 'show', 'messcat': category})
+        if response.status != 0:
+            return response
+        return NetworkResponse(status=0, data={'messages': response.data.get('result', {}).get('MessageList', [])})
+
+    def get_battle_report(self, task: Task, since: float, max_retries: int = 3) -> NetworkResponse:
+        """
+        Read the battle reports about the target of a task written since a time,
+        the fleets of a run are added up into one report.
+
+        Args:
+            task (Task): Task object.
+            since (float): Unix timestamp the fleets were sent at.
+            max_retries (int): Attempts before giving up on a report not written yet.
+
+        Returns:
+            NetworkResponse: Contains the battle payload if successful.
+        """
+        outcomes = {'a': 'win', 'r': 'lose', 'w': 'draw'}
+        for attempt in range(max_retries):
+            response = self._read_messages(3)
+            if response.status != 0:
+                return response
+            battles = []
+            for message in response.data['messages']:
+                battle = message.get('battle')
+                if not battle or int(message.get('time', 0)) < since:
+                    continue
+                if (int(battle.get('galaxy', 0)), int(battle.get('system', 0)), int(battle.get('planet', 0))) != \
+                        (task.target.galaxy, task.target.system, task.target.planet):
+                    continue
+                battles.append((int(message.get('time', 0)), battle))
+            if battles:
+                battles.sort(key=lambda item: item[0])
+                last = battles[-1][1]
+                report = {
+                    'outcome': outcomes.get(last.get('result'), 'draw'),
+                    'rounds': int(last.get('rounds', 0)),
+                    'loot': {'metal': 0, 'crystal': 0, 'deuterium': 0},
+                    'debris': {'metal': 0, 'crystal': 0, 'deuterium': 0},
+                    'attacker_losses': {},
+                    'defender_losses': {},
+                }
+                for _, battle in battles:
+                    for resource in ('metal', 'crystal', 'deuterium'):
+                        report['loot'][resource] += int(float(battle.get(resource, 0)))
+                        report['debris'][resource] += int(float(battle.get(f'debris_{resource}', 0)))
+                    for side in ('attacker', 'defender'):
+                        losses = report[f'{side}_losses']
+                        for unit, count in (battle.get(f'{side}_lost') or {}).items():
+                            name = IDToShip.get(f"ship{unit}", str(unit))
+                            losses[name] = losses.get(name, 0) + int(count)
+                return NetworkResponse(status=0, data=report)
+            logger.info(f"Battle report not found yet (attempt {attempt + 1}/{max_retries})")
+            time.sleep(5)
+        return NetworkResponse(status=-1, data={}, err_msg="Battle report not found.")
+
+    def get_expedition_report(self, task: Task, since: float, max_retries: int = 3) -> NetworkResponse:
+        """
+        Read the latest expedition message about the target of a task written since a time.
+
+        Args:
+            task (Task): Task object.
+            since (float): Unix timestamp the fleets were sent at.
+            max_retries (int): Attempts before giving up on a message not written yet.
+
+        Returns:
+            NetworkResponse: Contains the expedition payload if successful.
+        """
+        outcomes = {'nothing', 'resources', 'ships', 'dark_matter', 'pirates', 'aliens', 'delay', 'early', 'lost'}
+        for attempt in range(max_retries):
+            response = self._read_messages(15)
+            if response.status != 0:
+                return response
+            latest = None
+            for message in response.data['messages']:
+                expedition = message.get('expedition')
+                if not expedition or int(message.get('time', 0)) < since:
+                    continue
+                if (int(expedition.get('galaxy', 0)), int(expedition.get('system', 0))) != \
+                        (task.target.galaxy, task.target.system):
+                    continue
+                if latest is None or int(message.get('time', 0)) > int(latest[0].get('time', 0)):
+                    latest = (message, expedition)
+            if latest:
+                message, expedition = latest
+                outcome = expedition.get('type', 'nothing')
+
+                def ships(key):
+                    return {IDToShip.get(f"ship{ship}", str(ship)): int(count)
+                            for ship, count in (expedition.get(key) or {}).items()}
+
+                return NetworkResponse(status=0, data={
+                    'outcome': outcome if outcome in outcomes else 'nothing',
+                    'resources': {
+                        'metal': int(float(expedition.get('metal', 0))),
+                        'crystal': int(float(expedition.get('crystal', 0))),
+                        'deuterium': int(float(expedition.get('deuterium', 0))),
+                    },
+                    'dark_matter': int(float(expedition.get('darkmatter', 0))),
+                    'ships_found': ships('ships_found'),
+                    'ships_lost': ships('ships_lost'),
+                    'message': message.get('text', ''),
+                })
+            logger.info(f"Expedition message not found yet (attempt {attempt + 1}/{max_retries})")
+            time.sleep(5)
+        return NetworkResponse(status=-1, data={}, err_msg="Expedition message not found.")
+
     def handle_escape_task(self, task: Task) -> NetworkResponse:
         """
         Handle an escape task.
@@ -194,3 +429,93 @@ class Galaxy(Network):
         if not planet_id:
             return NetworkResponse(status=-1, data={}, err_msg="Planet not found")
         return NetworkResponse(status=0, data={'planet_id': planet_id})
+
+    def query_fleet(self) -> NetworkResponse:
+        """
+        Query the ships docked on every planet of the account.
+
+        Returns:
+            NetworkResponse: Contains the planets with their ship counts if successful.
+        """
+        FLEET_TABLE_ENDPOINT = "game.php?page=fleetTable"
+        logger.info("Querying fleet...")
+        if not self.planet_id_table:
+            response = self.change_planet()
+            if response.status != 0:
+                return response
+
+        planets = []
+        for planet_id, position in list(self.planet_id_table.items()):
+            if ":" in str(planet_id):
+                continue  # the table maps both ways
+            response = self._post(FLEET_TABLE_ENDPOINT, {"cp": planet_id})
+            if response.status != 0:
+                logger.error(f"Failed to query fleet of planet {planet_id}: {response.err_msg}")
+                return response
+            fleet = {ship: 0 for ship in IDToShip.values()}
+            for ship in response.data.get('result', {}).get('FleetsOnPlanet', []):
+                name = IDToShip.get(f"ship{ship.get('id')}")
+                if name:
+                    fleet[name] = int(ship.get('count', 0))
+            planets.append({'planet_id': int(planet_id), 'position': position, 'fleet': fleet})
+            time.sleep(1)
+        return NetworkResponse(status=0, data={'planets': planets})
+
+    def scan_galaxy(self, scan: ScanRange) -> NetworkResponse:
+        """
+        Walk the systems of a scan range in the galaxy view.
+
+        Args:
+            scan (ScanRange): Galaxy and systems to walk.
+
+        Returns:
+            NetworkResponse: Contains the occupied positions if successful.
+        """
+        GALAXY_ENDPOINT = "game.php?page=galaxy"
+        logger.info(f"Scanning galaxy {scan.galaxy} systems {scan.system_from}-{scan.system_to}...")
+        planets = []
+        for system in range(scan.system_from, scan.system_to + 1):
+            response = self._post(GALAXY_ENDPOINT, {'galaxy': scan.galaxy, 'system': system})
+            if response.status != 0:
+                logger.error(f"Failed to scan system {scan.galaxy}:{system}: {response.err_msg}")
+                return response
+            rows = response.data.get('result', {}).get('GalaxyRows') or {}
+            if isinstance(rows, dict):
+                rows = [dict(row, position=int(position)) for position, row in rows.items() if row]
+            for row in rows:
+                planet = row.get('planet') or {}
+                user = row.get('user') or {}
+                if not planet or not user:
+                    continue  # empty position or debris only
+                flags = set(user.get('class') or [])
+                moon = row.get('moon') or {}
+                activity = str(planet.get('activity') or row.get('lastActivity') or '')
+                if activity == '*':
+                    activity = '15'  # active within the last 15 minutes
+                planets.append({
+                    'system': system,
+                    'position': int(row.get('position', planet.get('planet', 0))),
+                    'planet_id': int(planet.get('id', 0)),
+                    'planet_name': planet.get('name', ''),
+                    'player_id': int(user.get('id', 0)),
+                    'player_name': user.get('username', ''),
+                    'alliance': (row.get('ally') or {}).get('tag', ''),
+                    'has_moon': bool(moon),
+                    'moon_id': int(moon.get('id', 0)) if moon else 0,
+                    'activity': {
+                        'inactive': 'inactive' in flags,
+                        'long_inactive': 'longinactive' in flags,
+                        'vacation': 'vacation' in flags,
+                        'banned': 'banned' in flags,
+                        'noob': 'noob' in flags,
+                        'strong': 'strong' in flags,
+                        'activity': int(activity) if activity.isdigit() else 0,
+                    },
+                })
+            time.sleep(1)
+        return NetworkResponse(status=0, data={
+            'galaxy': scan.galaxy,
+            'system_from': scan.system_from,
+            'system_to': scan.system_to,
+            'planets': planets,
+        })
@@ -12,7 +12,7 @@ from rabbitmq import RabbitMQPublisher, RabbitMQConsumer
 from task_process import TaskProcessor
 from config import (
     RABBITMQ_HOST, RABBITMQ_PORT, RABBITMQ_USER, RABBITMQ_PASS,
-    TASK_QUEUE, RESULT_QUEUE
+    TASK_QUEUE, INSTANT_QUEUE, RESULT_QUEUE
 )
 
 logging.basicConfig(
@@ -42,6 +42,13 @@ class Worker:
             username=RABBITMQ_USER,
             password=RABBITMQ_PASS
         )
+        # Tasks run on demand from the master skip the delayed exchange
+        self.instant_consumer = RabbitMQConsumer(
+            host=RABBITMQ_HOST,
+            port=RABBITMQ_PORT,
+            username=RABBITMQ_USER,
+            password=RABBITMQ_PASS
+        )
         self.task_processor = TaskProcessor(self.task_queue, self.result_queue)
 
     def publish_results(self, queue_name: str):
@@ -100,6 +107,7 @@ class Worker:
         """Start consuming messages using RabbitMQConsumer."""
         logger.info("Starting message consumer")
         self.consumer.start_consuming(TASK_QUEUE, self.handle_consumed_message)
+        self.instant_consumer.start_consuming(INSTANT_QUEUE, self.handle_consumed_message)
 
     def start(self):
         logger.info("Starting worker...")
@@ -130,6 +138,11 @@ class Worker:
                 self.consumer.stop_consuming()
             except Exception as e:
                 logger.error(f"Error stopping RabbitMQ consumer: {e}")
+        if self.instant_consumer:
+            try:
+                self.instant_consumer.stop_consuming()
+            except Exception as e:
+                logger.error(f"Error stopping RabbitMQ instant consumer: {e}")
 
         # Stop Publisher
         if self.publisher:
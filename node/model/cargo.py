@@ -0,0 +1,40 @@
+from dataclasses import dataclass
+from dataclasses_json import dataclass_json
+
+
+@dataclass_json
+@dataclass
+class Cargo:
+    metal: int = 0
+    crystal: int = 0
+    deuterium: int = 0
+    all: bool = False  # load every resource of the start planet
+
+    def to_resources(self):
+        return {'resource1': self.metal, 'resource2': self.crystal, 'resource3': self.deuterium}
+
+    def load(self, stock, capacity):
+        """
+        Resources a fleet actually carries, loaded metal first like the game does.
+
+        Args:
+            stock (dict): Resources of the start planet by resource key, None when unknown.
+            capacity (int): Cargo capacity of the fleet.
+
+        Returns:
+            dict: Loaded amounts by resource key, see to_resources.
+        """
+        wanted = dict(stock) if self.all else self.to_resources()
+        loaded = {}
+        for key in ('resource1', 'resource2', 'resource3'):
+            amount = max(0, wanted.get(key, 0))
+            if stock is not None:
+                amount = min(amount, max(0, stock.get(key, 0)))
+            amount = min(amount, capacity)
+            capacity -= amount
+            loaded[key] = amount
+        return loaded
+
+
+if __name__ == '__main__':
+    pass
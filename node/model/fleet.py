@@ -1,6 +1,6 @@
 from dataclasses import dataclass
 from dataclasses_json import dataclass_json
-from config import ShipToID
+from config import ShipToID, ShipCapacity
 
 
 @dataclass_json
@@ -22,6 +22,10 @@ class Fleet:
     def to_fleet(self):
         return {ShipToID[attr]: value for attr, value in self.__dict__.items()}
 
+    def capacity(self):
+        """Resources the whole fleet carries."""
+        return sum(ShipCapacity.get(attr, 0) * value for attr, value in self.__dict__.items())
+
 
 if __name__ == '__main__':
     pass
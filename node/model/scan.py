@@ -0,0 +1,14 @@
+from dataclasses import dataclass
+from dataclasses_json import dataclass_json
+
+
+@dataclass_json
+@dataclass
+class ScanRange:
+    galaxy: int
+    system_from: int
+    system_to: int  # included
+
+
+if __name__ == '__main__':
+    pass
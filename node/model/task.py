@@ -4,20 +4,28 @@ from dataclasses import dataclass
 from dataclasses_json import dataclass_json
 from model.user import Account
 from model.fleet import Fleet
+from model.cargo import Cargo
+from model.scan import ScanRange
 from model.target import Target
 
 
 class TaskType(Enum):
     ATTACK = 1
+    TRANSPORT = 3
     EXPLORE = 4
+    SPY = 6
     ESCAPE = "escape"
     LOGIN = 99
     QUERY_PLANET = 100
+    QUERY_FLEET = 101
+    SCAN_GALAXY = 102
 
 
 class MissionType(Enum):
     ATTACK = 1
+    TRANSPORT = 3
     EXPLORE = 15
+    SPY = 6
     ESCAPE = "escape"  # TODO: check this later
 
 
@@ -25,6 +33,7 @@ class TaskStatus(Enum):
     RUNNING = 0
     SUCCESS = 1
     FAILED = 2
+    REPORT = 4  # report of a run read after its result, sent under the same uuid
 
 
 @dataclass_json
@@ -39,6 +48,8 @@ class Task:
     start_planet_id: int
     start_planet: Target
     target: Target
+    cargo: Optional[Cargo] = None  # only for transport tasks
+    scan: Optional[ScanRange] = None  # only for galaxy scans
 
 
 @dataclass_json
@@ -51,6 +62,7 @@ class TaskResult:
     back_ts: int = 0
     msg: Optional[str] = ""  # json string
     err_msg: Optional[str] = ""
+    data: Optional[dict] = None  # typed payload of the task type, read by the master before msg
 
 
 if __name__ == "__main__":
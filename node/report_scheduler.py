@@ -0,0 +1,70 @@
+import heapq
+import itertools
+import logging
+import threading
+import time
+from queue import Queue
+from concurrent.futures import ThreadPoolExecutor
+from typing import Callable, Optional
+from model.task import TaskResult
+
+
+class ReportScheduler:
+    """
+    Read mission reports once the game has written them, without holding a
+    worker of the task executor while the fleets fly.
+    """
+
+    def __init__(self, result_queue: Queue, max_workers=2):
+        self.result_queue = result_queue
+        self.executor = ThreadPoolExecutor(max_workers=max_workers)
+        self.is_running = True
+        self._pending = []  # heap of (due timestamp, sequence, read)
+        self._sequence = itertools.count()
+        self._condition = threading.Condition()
+        self._logger = logging.getLogger(__name__)
+        self._thread = threading.Thread(target=self._run, daemon=True)
+        self._thread.start()
+
+    def schedule(self, due: float, read: Callable[[], Optional[TaskResult]]) -> None:
+        """
+        Call read once the unix timestamp due has passed and queue the result it returns.
+
+        Args:
+            due (float): Unix timestamp the report is written at
+            read (Callable): Reads the report, returns None when there is nothing to send
+        """
+        with self._condition:
+            heapq.heappush(self._pending, (due, next(self._sequence), read))
+            self._condition.notify()
+
+    def _run(self):
+        while True:
+            with self._condition:
+                while self.is_running and (not self._pending or self._pending[0][0] > time.time()):
+                    timeout = self._pending[0][0] - time.time() if self._pending else None
+                    self._condition.wait(timeout)
+                if not self.is_running:
+                    return
+                _, _, read = heapq.heappop(self._pending)
+            self.executor.submit(self._read, read)
+
+    def _read(self, read: Callable[[], Optional[TaskResult]]):
+        try:
+            result = read()
+        except Exception as e:
+            self._logger.exception(f"Error reading report: {str(e)}")
+            return
+        if result is not None:
+            self.result_queue.put(result)
+            self._logger.debug(f"Report queued: {result}")
+
+    def shutdown(self):
+        with self._condition:
+            self.is_running = False
+            pending = len(self._pending)
+            self._condition.notify()
+        self.executor.shutdown(wait=True)
+        if pending:
+            self._logger.warning(f"{pending} reports not read before shutdown")
+        self._logger.info("ReportScheduler shutdown complete")
@@ -1,10 +1,16 @@
 import logging
+from functools import partial
 from queue import Queue, Empty
 from concurrent.futures import ThreadPoolExecutor
 from model.task import Task, TaskType, TaskResult, TaskStatus
 from actions.login import login_action
 from actions.attack import attack_action, explore_action
 from actions.query_planet import query_planet_action
+from actions.query_fleet import query_fleet_action
+from actions.transport import transport_action
+from actions.spy import spy_action
+from actions.scan_galaxy import scan_galaxy_action
+from report_scheduler import ReportScheduler
 
 
 class TaskProcessor:
@@ -12,6 +18,7 @@ class TaskProcessor:
         self.task_queue = task_queue
         self.result_queue = result_queue
         self.executor = ThreadPoolExecutor(max_workers=max_workers)
+        self.reports = ReportScheduler(result_queue)  # reports are read outside of the executor
         self.is_running = True
         self._logger = logging.getLogger(__name__)
 
@@ -23,13 +30,24 @@ class TaskProcessor:
 
             action_map = {
                 TaskType.LOGIN: login_action,
-                TaskType.ATTACK: attack_action,
-                TaskType.EXPLORE: explore_action,
-                TaskType.QUERY_PLANET: query_planet_action
+                TaskType.ATTACK: partial(attack_action, reports=self.reports),
+                TaskType.TRANSPORT: transport_action,
+                TaskType.EXPLORE: partial(explore_action, reports=self.reports),
+                TaskType.SPY: partial(spy_action, reports=self.reports),
+                TaskType.QUERY_PLANET: query_planet_action,
+                TaskType.QUERY_FLEET: query_fleet_action,
+                TaskType.SCAN_GALAXY: scan_galaxy_action
             }
 
             action = action_map.get(task.task_type)
             if action:
+                # Let the master know the task left the queue
+                self.result_queue.put(TaskResult(
+                    task_id=task.task_id,
+                    status=TaskStatus.RUNNING,
+                    task_type=task.task_type,
+                    uuid=task.uuid
+                ))
                 self.executor.submit(
                     action,
                     task,
@@ -86,4 +104,5 @@ class TaskProcessor:
     def shutdown(self):
         self.is_running = False
         self.executor.shutdown(wait=True)
+        self.reports.shutdown()
         self._logger.info("TaskProcessor shutdown complete")